@@ -0,0 +1,59 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/types"
+)
+
+// accumulatorParam is the type of the accumulator parameter the destructuring
+// and consumer methods take last, eg. *int, or "" when there's none.
+func (gen *generator) accumulatorParam() string {
+	if gen.AccumulatorType == "" {
+		return ""
+	}
+	// NOTE: Validate made sure the type parses, so that the spelling of it
+	// doesn't matter.
+	typ, err := parser.ParseExpr(gen.AccumulatorType)
+	if err != nil {
+		return "*" + gen.AccumulatorType
+	}
+	return "*" + types.ExprString(typ)
+}
+
+// withoutAccumulator checks that a consumer method takes the accumulator as its
+// last parameter and returns a copy of it without that, so that the remaining
+// parameters describe the variant fields.
+func (gen *generator) withoutAccumulator(method *ast.Field) (*ast.Field, error) {
+	want := gen.accumulatorParam()
+	if want == "" {
+		return method, nil
+	}
+
+	typ := method.Type.(*ast.FuncType)
+	params := typ.Params.List
+	if len(params) == 0 || types.ExprString(params[len(params)-1].Type) != want {
+		return nil, gen.errorAt(method,
+			"consumer method %s should take a %s accumulator as its last argument",
+			method.Names[0].Name, want)
+	}
+
+	fields := copyFieldList(typ.Params)
+	last := fields.List[len(fields.List)-1]
+	if len(last.Names) > 1 {
+		last.Names = last.Names[:len(last.Names)-1]
+	} else {
+		fields.List = fields.List[:len(fields.List)-1]
+	}
+
+	return &ast.Field{
+		Doc:     method.Doc,
+		Names:   method.Names,
+		Type:    &ast.FuncType{TypeParams: typ.TypeParams, Params: fields, Results: typ.Results},
+		Comment: method.Comment,
+	}, nil
+}
@@ -0,0 +1,47 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateAssertions builds compile-time checks that the variants implement
+// the composite, eg.
+//
+//	var (
+//		_ Expr = (*Lit)(nil)
+//		_ Expr = (*Add)(nil)
+//	)
+//
+// With value receivers, it's the zero values of the variants that get checked.
+// A generic composite can only be referred to with its type parameters in
+// scope, so then the checks go in a function declaring them.
+func (gen *generator) generateAssertions(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+
+	var specs []string
+	for _, variant := range variants {
+		value := fmt.Sprintf("(*%s)(nil)", gen.variantType(variant))
+		if gen.ValueReceiver {
+			value = gen.variantType(variant) + "{}"
+		}
+		specs = append(specs, fmt.Sprintf("_ %s = %s", composite, value))
+	}
+
+	var src string
+	switch {
+	case gen.composite.TypeParams != nil:
+		src = fmt.Sprintf("func _%s() {\nvar (\n%s\n)\n}\n", gen.typeParamsDecl(), strings.Join(specs, "\n"))
+	case len(specs) == 1:
+		src = "var " + specs[0] + "\n"
+	default:
+		src = "var (\n" + strings.Join(specs, "\n") + "\n)\n"
+	}
+
+	return gen.parseDecls(src)
+}
@@ -0,0 +1,69 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// generateBase builds a consumer implementation doing nothing, eg.
+//
+//	type BaseExprConsumer struct{}
+//
+//	func (BaseExprConsumer) Lit(N int)       {}
+//	func (BaseExprConsumer) Var(Name string) {}
+//
+// Embedding it in a struct lets that implement the consumer while overriding
+// only the methods for the variants it cares about. Methods with results
+// return zero values.
+func (gen *generator) generateBase() []ast.Decl {
+	baseName := "Base" + gen.consumerName()
+
+	base := &ast.GenDecl{
+		Tok: token.TYPE,
+		Specs: []ast.Spec{&ast.TypeSpec{
+			Name:       &ast.Ident{Name: baseName},
+			TypeParams: gen.typeParams(),
+			Type:       &ast.StructType{Fields: &ast.FieldList{}},
+		}},
+	}
+
+	decls := []ast.Decl{base}
+	for _, method := range gen.variants {
+		methodType := method.Type.(*ast.FuncType)
+
+		// NOTE: The results get blank names, so that a bare return
+		// statement returns the zero values.
+		var results *ast.FieldList
+		if methodType.Results.NumFields() > 0 {
+			results = copyFieldList(methodType.Results)
+			for _, field := range results.List {
+				if len(field.Names) == 0 {
+					field.Names = []*ast.Ident{{}}
+				}
+				for _, name := range field.Names {
+					name.Name = "_"
+				}
+			}
+		}
+
+		body := &ast.BlockStmt{}
+		if results != nil {
+			body.List = []ast.Stmt{&ast.ReturnStmt{}}
+		}
+
+		decls = append(decls, &ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{{Type: gen.instantiate(baseName)}}},
+			Name: &ast.Ident{Name: method.Names[0].Name},
+			Type: &ast.FuncType{
+				Params:  copyFieldList(methodType.Params),
+				Results: results,
+			},
+			Body: body,
+		})
+	}
+	return decls
+}
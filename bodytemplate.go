@@ -0,0 +1,97 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"text/template"
+)
+
+// MethodBodyData is what Config.MethodBodyTemplate gets executed with, for
+// each variant.
+type MethodBodyData struct {
+	// The name of the variant type, eg. Lit.
+	Variant string
+
+	// The name of the composite method the variant implements, eg. FeedTo.
+	Method string
+
+	// The names of the method receiver and of its consumer argument.
+	Receiver, Consumer string
+
+	// The names of the variant fields, in order.
+	Fields []string
+
+	// The call passing the variant to the consumer, eg. consumer.Lit(e.N),
+	// which is what the method body consists of by default.
+	Call string
+
+	// Whether the method has results, which the body then has to return.
+	Returns bool
+}
+
+// withTemplateBody replaces the body of the method generated for a variant
+// with the statements Config.MethodBodyTemplate produces for it.
+func (gen *generator) withTemplateBody(variant *ast.TypeSpec, fun *ast.FuncDecl) (*ast.FuncDecl, error) {
+	if gen.bodyTemplate == nil {
+		tmpl, err := template.New("body").Parse(gen.MethodBodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse the method body template: %w", err)
+		}
+		gen.bodyTemplate = tmpl
+	}
+
+	data := MethodBodyData{
+		Variant:  variant.Name.Name,
+		Method:   fun.Name.Name,
+		Receiver: gen.receiverName(),
+		Consumer: "consumer",
+	}
+	for _, field := range gen.variantFields(variant) {
+		data.Fields = append(data.Fields, field.Name)
+	}
+
+	var call ast.Expr
+	switch stmt := fun.Body.List[0].(type) {
+	case *ast.ReturnStmt:
+		call, data.Returns = stmt.Results[0], true
+	case *ast.ExprStmt:
+		call = stmt.X
+	}
+	var buf bytes.Buffer
+	err := format.Node(&buf, token.NewFileSet(), call)
+	if err != nil {
+		return nil, err
+	}
+	data.Call = buf.String()
+
+	var body bytes.Buffer
+	err = gen.bodyTemplate.Execute(&body, data)
+	if err != nil {
+		return nil, fmt.Errorf("can't execute the method body template for variant %s: %w", data.Variant, err)
+	}
+
+	// NOTE: The method is printed without a body and parsed back with the
+	// new one, so that all of its nodes have positions the printer can lay
+	// the statements out by.
+	header := *fun
+	header.Body = &ast.BlockStmt{}
+	buf.Reset()
+	err = format.Node(&buf, token.NewFileSet(), &header)
+	if err != nil {
+		return nil, err
+	}
+	src := bytes.TrimSpace(bytes.TrimSuffix(bytes.TrimSpace(buf.Bytes()), []byte("}")))
+
+	decls, err := gen.parseDecls(string(src) + "\n" + body.String() + "\n}\n")
+	if err != nil {
+		return nil, fmt.Errorf("the method body template gives variant %s an invalid body: %w", data.Variant, err)
+	}
+	return decls[0].(*ast.FuncDecl), nil
+}
@@ -0,0 +1,61 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/szabba/irgen"
+)
+
+// fileConfig is what a -config file holds: the library config, with the keys
+// named after its fields, and the options only the command has. Eg.
+//
+//	{
+//		"TypeNames": {"Composite": "Expr", "Consumer": "ExprConsumer"},
+//		"Pairs": [{"Composite": "Pattern", "Consumer": "PatternConsumer"}],
+//		"GenerateEqual": true,
+//		"BuildTags": ["!race"],
+//		"Out": "expr_gen.go"
+//	}
+type fileConfig struct {
+	irgen.Config
+
+	Out, Dir, HeaderFile string
+}
+
+// loadConfigFile replaces the config with the one in the file, and then parses
+// the arguments with the flags again, so that the flags given explicitly take
+// precedence over the file.
+func loadConfigFile(filename string, config *irgen.Config, flags *flag.FlagSet, args []string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var file fileConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&file)
+	if err != nil {
+		return fmt.Errorf("can't read config file %s: %w", filename, err)
+	}
+
+	*config = file.Config
+	outputFileName, outputDir, headerFileName = file.Out, file.Dir, file.HeaderFile
+
+	// NOTE: The -file flag appends to the list, so the files from the config
+	// file would otherwise be kept alongside the ones given explicitly.
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "file" {
+			config.Files = nil
+		}
+	})
+	return flags.Parse(args)
+}
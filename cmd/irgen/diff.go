@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/szabba/irgen/internal/linediff"
+)
+
+// writeDiff writes the lines that differ between the old and the new text,
+// prefixed with - and + respectively. Each run of changes is headed by the
+// line numbers it starts at, eg. @@ -12 +12 @@.
+func writeDiff(w io.Writer, old, new []byte) {
+	inRun := false
+	for _, edit := range linediff.Lines(splitLines(string(old)), splitLines(string(new))) {
+		if edit.Op == ' ' {
+			inRun = false
+			continue
+		}
+
+		if !inRun {
+			fmt.Fprintf(w, "@@ -%d +%d @@\n", edit.Old+1, edit.New+1)
+			inRun = true
+		}
+		fmt.Fprintf(w, "%c%s\n", edit.Op, edit.Line)
+	}
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
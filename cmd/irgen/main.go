@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
@@ -19,64 +20,322 @@ import (
 
 var (
 	outputFileName string
+	outputDir      string
+	headerFileName string
+	templateFile   string
+	configFileName string
+	sourceDir      string
+	sourcePackage  string
+	toStdout       bool
 	verbose        bool
+	check          bool
 )
 
 func main() {
+	var config irgen.Config
+
+	flag.StringVar(&configFileName, "config", "", "JSON file with the options, which the flags given explicitly override")
+	flag.StringVar(&sourceDir, "srcdir", "", "directory of the source package (the one of $GOFILE if \"\")")
+	flag.StringVar(&sourcePackage, "pkg", "", "name of the source package ($GOPACKAGE if \"\")")
 	flag.StringVar(&outputFileName, "out", "", "name for the output file (computed if \"\", stdout if \"-\")")
-	flag.BoolVar(&verbose, "v", false, "if true, copy all output to stdout, besides the output file")
+	flag.BoolVar(&toStdout, "stdout", false, "if true, write the output to stdout, like -out -")
+	flag.StringVar(&outputDir, "dir", "", "directory to write a file per composite to, instead of a single output file")
+	flag.BoolVar(&check, "check", false, "if true, only check that the output is up to date, printing a diff and failing when it's not")
+	flag.BoolVar(&verbose, "v", false, "if true, report progress on stderr and copy all output to stdout, besides the output file")
+	flag.StringVar(&config.OutputPackageName, "outpkg", "", "name of the package the generated code belongs to (the source package if \"\")")
+	flag.StringVar(&config.CompositeImportPath, "import-path", "", "import path of the source package, for -outpkg (worked out from the directory if \"\")")
+	flag.Var((*fileList)(&config.Files), "file", "a file of the package to parse (can be repeated; all of them if none)")
+	flag.StringVar(&config.TypeNames.VariantPrefix, "prefix", "", "prefix added to the variant type names")
+	flag.StringVar(&config.TypeNames.VariantSuffix, "suffix", "", "suffix added to the variant type names")
+	flag.StringVar(&config.MethodName, "method", "", "name of the composite method to generate (any if \"\")")
+	flag.BoolVar(&config.ExpandGroups, "expand-groups", false, "if true, declare each variant field on its own line, even when the parameters are declared together")
+	flag.BoolVar(&config.TypesOnly, "types-only", false, "if true, generate only the variant types, without the methods implementing the composite")
+	flag.BoolVar(&config.MethodsOnly, "methods-only", false, "if true, generate only the methods implementing the composite, for variant structs declared by hand")
+	flag.BoolVar(&config.ValueReceiver, "value-receiver", false, "if true, generate methods with value receivers instead of pointer ones")
+	flag.StringVar(&config.ReceiverName, "receiver", "", "name of the receiver in the generated methods (computed if \"\")")
+	flag.StringVar(&config.AccumulatorType, "acc", "", "type of an accumulator the composite method takes a pointer to after the consumer (none if \"\")")
+	flag.BoolVar(&config.StrictNames, "strict-names", false, "if true, fail on unnamed and blank consumer method arguments instead of naming the fields ArgN")
+	flag.BoolVar(&config.FallibleVisitor, "fallible", false, "if true, require the composite and consumer methods to return an error, which the variants pass on")
+	flag.BoolVar(&config.EmitAssertions, "assert", false, "if true, generate compile-time assertions that the variants implement the composite")
+	flag.BoolVar(&config.EmitVariantManifest, "manifest", false, "if true, list the variants of each composite in an //irgen:variants comment, for linters")
+	flag.BoolVar(&config.Constructors, "constructors", false, "if true, generate a MakeX constructor for each variant X")
+	flag.BoolVar(&config.ConcreteConstructors, "new", false, "if true, generate a NewX constructor for each variant X, returning a *X")
+	flag.BoolVar(&config.ValidatingConstructors, "validate", false, "if true, generate a NewX constructor for each variant X, returning an error from validateX if there is one")
+	flag.BoolVar(&config.GenerateMatch, "match", false, "if true, generate a MatchX function taking a handler function per variant of X")
+	flag.BoolVar(&config.GenerateBase, "base", false, "if true, generate a BaseX struct with no-op methods implementing the consumer X")
+	flag.BoolVar(&config.GenerateEqual, "equal", false, "if true, generate an Equal method on each variant")
+	flag.BoolVar(&config.GenerateHash, "hash", false, "if true, generate a Hash method on each variant, consistent with Equal")
+	flag.BoolVar(&config.GenerateCopy, "copy", false, "if true, generate a Copy method on each variant, returning a deep copy")
+	flag.BoolVar(&config.GenerateString, "string", false, "if true, generate a String method on each variant")
+	flag.BoolVar(&config.GenerateFold, "fold", false, "if true, generate a FoldX function taking an XHandlers struct with a function per variant of X")
+	flag.BoolVar(&config.GenerateWalk, "walk", false, "if true, generate a WalkX function visiting every node of a tree of X values")
+	flag.BoolVar(&config.GenerateWalkParent, "walk-parent", false, "if true, generate a WalkXWithParent function visiting every node of a tree of X values along with its parent")
+	flag.BoolVar(&config.GenerateMap, "map", false, "if true, generate a MapX function rebuilding a tree of X values bottom-up")
+	flag.BoolVar(&config.GenerateSeq, "seq", false, "if true, generate an XAll function returning an iterator over every node of a tree of X values")
+	flag.BoolVar(&config.GenerateKind, "kind", false, "if true, generate an XKind enum for the composite X and a Kind method on each variant")
+	flag.BoolVar(&config.GenerateTag, "tag-method", false, "if true, generate a Tag method on each variant, returning its name")
+	flag.BoolVar(&config.GenerateJSON, "json", false, "if true, generate JSON marshalling for the variants and an XJSON wrapper unmarshalling them")
+	flag.BoolVar(&config.Sealed, "sealed", false, "if true, generate an unexported marker method on each variant, sealing the composite")
+	flag.BoolVar(&config.DryRun, "n", false, "if true, only check the source and describe what would be generated on stderr")
+	flag.BoolVar(&config.Verify, "verify", false, "if true, type check the generated code before writing it")
+	flag.StringVar(&config.LicenseHeader, "license", "", "license notice for the top of the generated file: mpl2, apache2 or an SPDX license expression")
+	flag.StringVar(&config.Header, "header", "", "text to put at the top of the generated file, before the generated code marker")
+	flag.StringVar(&headerFileName, "header-file", "", "file to read the -header text from")
+	flag.StringVar(&templateFile, "body-template-file", "", "file with a text/template producing the body of the method each variant implements the composite with")
+	flag.BoolVar(&config.OmitHeader, "no-header", false, "if true, leave out the generated code marker")
+	flag.BoolVar(&config.LineDirectives, "line-directives", false, "if true, point the variant fields at the consumer methods through line directives, for compiler errors")
+	flag.Var((*tagList)(&config.BuildTags), "tags", "comma-separated build tags required by the generated file")
+	flag.Var((*tagList)(&config.LintSuppressions), "nolint", "comma-separated linters to silence on the generated declarations with //nolint comments")
 	flag.Parse()
 
-	var config irgen.Config
+	if configFileName != "" {
+		err := loadConfigFile(configFileName, &config, flag.CommandLine, os.Args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if headerFileName != "" {
+		header, err := ioutil.ReadFile(headerFileName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.Header = string(header)
+	}
 
-	if os.Getenv("GOFILE") == "" {
-		log.Fatalf("environment variable GOFILE missing or empty")
+	if templateFile != "" {
+		tmpl, err := ioutil.ReadFile(templateFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		config.MethodBodyTemplate = string(tmpl)
 	}
-	config.Directory = filepath.Dir(os.Getenv("GOFILE"))
 
-	if os.Getenv("GOPACKAGE") == "" {
-		log.Fatalf("environment variable GOPACKAGE missing or empty")
+	// NOTE: The flags are there for running irgen outside of go generate, eg.
+	// from a Makefile, so they take precedence over the environment.
+	switch gofile := os.Getenv("GOFILE"); {
+	case sourceDir != "":
+		config.Directory = sourceDir
+	case gofile != "":
+		config.Directory = filepath.Dir(gofile)
+	}
+	switch gopackage := os.Getenv("GOPACKAGE"); {
+	case sourcePackage != "":
+		config.PackageName = sourcePackage
+	case gopackage != "":
+		config.PackageName = gopackage
 	}
-	config.PackageName = os.Getenv("GOPACKAGE")
 
-	if flag.NArg() != 2 {
-		log.Fatalf("two arguments wanted: COMPOSITE and CONSUMER")
+	// NOTE: Pairs given as arguments replace any the config file names.
+	// Without either, they come from the //irgen:pair directives in the file
+	// go generate runs irgen for.
+	var pairs []irgen.TypeNames
+	for i := 0; i < flag.NArg(); i += 2 {
+		pairs = append(pairs, irgen.TypeNames{Composite: flag.Arg(i), Consumer: flag.Arg(i + 1)})
+	}
+	gofile := os.Getenv("GOFILE")
+	if len(pairs) == 0 && config.TypeNames.Composite == "" && len(config.Pairs) == 0 && gofile != "" {
+		var err error
+		pairs, err = pairDirectives(gofile)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	config.TypeNames.Composite = flag.Arg(0)
-	config.TypeNames.Consumer = flag.Arg(1)
+	prefix, suffix := config.TypeNames.VariantPrefix, config.TypeNames.VariantSuffix
+	if len(pairs) > 0 {
+		config.Pairs = nil
+	}
+	for i, names := range pairs {
+		names.VariantPrefix, names.VariantSuffix = prefix, suffix
+
+		if i == 0 {
+			config.TypeNames = names
+		} else {
+			config.Pairs = append(config.Pairs, names)
+		}
+	}
+
+	if verbose {
+		config.Logf = log.Printf
+	}
+
+	err := config.Validate()
+	if err != nil {
+		// NOTE: The directory and package come from $GOFILE and
+		// $GOPACKAGE, which go generate sets, unless they're given
+		// explicitly.
+		log.Fatalf("%s\nusage: irgen [flags] COMPOSITE CONSUMER [COMPOSITE CONSUMER ...] (run through go generate, or with -srcdir and -pkg)", err)
+	}
+
+	if toStdout {
+		if outputFileName != "" && outputFileName != "-" {
+			log.Fatalf("only one of -out and -stdout can be given")
+		}
+		outputFileName = "-"
+	}
+	if outputDir != "" && outputFileName != "" {
+		log.Fatalf("only one of -out (or -stdout) and -dir can be given")
+	}
+	if outputDir == "" && outputFileName == "" {
+		// NOTE: go generate runs in the source directory, but with -srcdir
+		// that can be anywhere.
+		outputFileName = filepath.Join(config.Directory, config.DefaultOutputName())
+	}
+	if outputFileName != "" && outputFileName != "-" {
+		config.OutputFile = outputFileName
+		if rel, err := filepath.Rel(config.Directory, outputFileName); err == nil {
+			config.OutputFile = rel
+		}
+	}
+
+	if check {
+		upToDate, err := checkOutput(config, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !upToDate {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if outputDir != "" {
+		err := writeDir(config, outputDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	var buf bytes.Buffer
-	err := config.Generate(&buf)
+	err = config.Generate(&buf)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	var out io.Writer
+	if config.DryRun {
+		if outputFileName != "-" {
+			fmt.Fprintf(os.Stderr, "would write %s\n", outputFileName)
+		}
+		return
+	}
 
 	if outputFileName == "-" {
-		out = os.Stdout
-
-	} else {
-		if outputFileName == "" {
-			outputFileName = fmt.Sprintf("%s_impl.go", strings.ToLower(config.TypeNames.Composite))
+		_, err = io.Copy(os.Stdout, &buf)
+		if err != nil {
+			log.Fatal(err)
 		}
+		return
+	}
 
-		file, err := os.Create(outputFileName)
+	err = writeFile(outputFileName, buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if verbose {
+		os.Stdout.Write(buf.Bytes())
+	}
+}
+
+// writeDir writes the code generated for each composite to a file of its own in
+// the directory.
+func writeDir(config irgen.Config, dir string) error {
+	for _, single := range config.PerComposite() {
+		filename := filepath.Join(dir, single.DefaultOutputName())
+		single.OutputFile = filename
+
+		src, err := single.GenerateBytes()
 		if err != nil {
-			log.Fatal(err)
+			return err
+		}
+		if config.DryRun {
+			fmt.Fprintf(os.Stderr, "would write %s\n", filename)
+			continue
 		}
-		defer file.Close()
 
+		err = writeFile(filename, src)
+		if err != nil {
+			return err
+		}
 		if verbose {
-			out = io.MultiWriter(file, os.Stdout)
-		} else {
-			out = file
+			os.Stdout.Write(src)
+		}
+	}
+	return nil
+}
+
+// checkOutput tells whether the output files are up to date, writing a diff
+// for each one that's not to w.
+func checkOutput(config irgen.Config, w io.Writer) (bool, error) {
+	if outputDir == "" {
+		if outputFileName == "-" {
+			return false, fmt.Errorf("-check needs an output file to compare with, not stdout")
 		}
+		return checkFile(config, outputFileName, w)
 	}
 
-	_, err = io.Copy(out, &buf)
+	upToDate := true
+	for _, single := range config.PerComposite() {
+		filename := filepath.Join(outputDir, single.DefaultOutputName())
+		single.OutputFile = filename
+
+		ok, err := checkFile(single, filename, w)
+		if err != nil {
+			return false, err
+		}
+		upToDate = upToDate && ok
+	}
+	return upToDate, nil
+}
+
+// checkFile tells whether the file holds what would be generated now, writing
+// a diff to w when it doesn't. A missing file is not up to date.
+func checkFile(config irgen.Config, filename string, w io.Writer) (bool, error) {
+	existing, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+
+	upToDate, err := config.IsUpToDate(existing)
+	if err != nil || upToDate {
+		return upToDate, err
+	}
+
+	src, err := config.GenerateBytes()
 	if err != nil {
-		log.Fatal(err)
+		return false, err
 	}
+	fmt.Fprintf(w, "%s is out of date:\n", filename)
+	writeDiff(w, existing, src)
+	return false, nil
+}
+
+// A flag.Value for a comma-separated list, like the one of build tags.
+type tagList []string
+
+func (tags *tagList) String() string {
+	return strings.Join(*tags, ",")
+}
+
+func (tags *tagList) Set(value string) error {
+	*tags = nil
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			*tags = append(*tags, tag)
+		}
+	}
+	return nil
+}
+
+// A flag.Value collecting the file names from repeated flags.
+type fileList []string
+
+func (files *fileList) String() string {
+	return strings.Join(*files, ",")
+}
+
+func (files *fileList) Set(value string) error {
+	*files = append(*files, value)
+	return nil
 }
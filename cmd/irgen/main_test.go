@@ -0,0 +1,409 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/szabba/irgen"
+)
+
+// When IRGEN_RUN_MAIN is set, the test binary runs the command instead, so that
+// tests can invoke it like a user would.
+func TestMain(m *testing.M) {
+	if os.Getenv("IRGEN_RUN_MAIN") == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with the arguments and without the variables go
+// generate sets, returning what it prints.
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "GOFILE=") && !strings.HasPrefix(v, "GOPACKAGE=") {
+			cmd.Env = append(cmd.Env, v)
+		}
+	}
+	cmd.Env = append(cmd.Env, "IRGEN_RUN_MAIN=1")
+
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestWriteDir(t *testing.T) {
+	config := irgen.Config{
+		Directory:   filepath.FromSlash("../../internal/test_cases/prefix"),
+		PackageName: "prefix",
+	}
+	config.TypeNames = irgen.TypeNames{Composite: "Expr", Consumer: "ExprConsumer", VariantPrefix: "Expr"}
+	config.Pairs = []irgen.TypeNames{{Composite: "Pattern", Consumer: "PatternConsumer", VariantSuffix: "Pattern"}}
+
+	dir := t.TempDir()
+	err := writeDir(config, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"expr_impl.go", "pattern_impl.go"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	config := irgen.Config{
+		Directory:   filepath.FromSlash("../../internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+	}
+	config.TypeNames = irgen.TypeNames{Composite: "Expr", Consumer: "ExprConsumer"}
+
+	current, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "ref.go")
+
+	err = ioutil.WriteFile(filename, current, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	upToDate, err := checkFile(config, filename, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upToDate || out.Len() > 0 {
+		t.Errorf("got up to date %t and output %q for the current file, want true and none", upToDate, out.String())
+	}
+
+	stale := bytes.Replace(current, []byte("N int"), []byte("N int64"), 1)
+	err = ioutil.WriteFile(filename, stale, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	upToDate, err = checkFile(config, filename, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upToDate {
+		t.Error("a stale file is reported as up to date")
+	}
+	for _, want := range []string{"is out of date", "-\tN int64\n", "+\tN int\n"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, stale) {
+		t.Error("checking rewrote the file")
+	}
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	defer func(out, dir, header string) {
+		outputFileName, outputDir, headerFileName = out, dir, header
+	}(outputFileName, outputDir, headerFileName)
+
+	filename := filepath.Join(t.TempDir(), "irgen.json")
+	err := ioutil.WriteFile(filename, []byte(`{
+	"Directory": "../../internal/test_cases/intexpr",
+	"PackageName": "intexpr",
+	"TypeNames": {"Composite": "Expr", "Consumer": "ExprConsumer"},
+	"GenerateEqual": true,
+	"GenerateString": true,
+	"Out": "expr_gen.go"
+}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config irgen.Config
+	flags := flag.NewFlagSet("irgen", flag.ContinueOnError)
+	flags.BoolVar(&config.EmitAssertions, "assert", false, "")
+	flags.BoolVar(&config.GenerateString, "string", false, "")
+	args := []string{"-assert", "-string=false"}
+	err = flags.Parse(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = loadConfigFile(filename, &config, flags, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if outputFileName != "expr_gen.go" {
+		t.Errorf("got output file %q, want expr_gen.go", outputFileName)
+	}
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"func (e *Lit) Equal(", "_ Expr = (*Lit)(nil)"} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("the output does not contain %q:\n%s", want, src)
+		}
+	}
+	if bytes.Contains(src, []byte("String() string")) {
+		t.Errorf("the -string=false flag did not override the config file:\n%s", src)
+	}
+}
+
+func TestLoadConfigFileRejectsUnknownKeys(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "irgen.json")
+	err := ioutil.WriteFile(filename, []byte(`{"GenerateEquals": true}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var config irgen.Config
+	err = loadConfigFile(filename, &config, flag.NewFlagSet("irgen", flag.ContinueOnError), nil)
+	if err == nil || !strings.Contains(err.Error(), "GenerateEquals") {
+		t.Errorf("got error %v, want one about the unknown key", err)
+	}
+}
+
+func TestWriteAtomicallyKeepsFileOnError(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "expr_impl.go")
+	err := ioutil.WriteFile(filename, []byte("package expr\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("disk full")
+	err = writeAtomically(filename, func(w io.Writer) error {
+		io.WriteString(w, "package ex")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package expr\n" {
+		t.Errorf("the file was changed to %q", data)
+	}
+	assertOnlyFile(t, dir, "expr_impl.go")
+}
+
+func TestWriteFileReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "expr_impl.go")
+	err := ioutil.WriteFile(filename, []byte("package old\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writeFile(filename, []byte("package expr\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "package expr\n" {
+		t.Errorf("got file contents %q, want %q", data, "package expr\n")
+	}
+	assertOnlyFile(t, dir, "expr_impl.go")
+}
+
+// assertOnlyFile checks that the directory holds nothing but the named file,
+// eg. no temporary files left behind.
+func assertOnlyFile(t *testing.T, dir, name string) {
+	t.Helper()
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != name {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("got files %q, want only %s", names, name)
+	}
+}
+
+func TestRunWithoutGoGenerate(t *testing.T) {
+	dir := t.TempDir()
+	src := `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`
+	err := ioutil.WriteFile(filepath.Join(dir, "expr.go"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := runCommand(t, "-srcdir", dir, "-pkg", "expr", "Expr", "ExprConsumer")
+	if err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+
+	generated, err := ioutil.ReadFile(filepath.Join(dir, "expr_impl.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(generated, []byte("func (e *Lit) FeedTo(consumer ExprConsumer)")) {
+		t.Errorf("unexpected output file:\n%s", generated)
+	}
+}
+
+func TestRunWithoutPackage(t *testing.T) {
+	out, err := runCommand(t, "-srcdir", t.TempDir(), "Expr", "ExprConsumer")
+	if err == nil {
+		t.Fatalf("the command succeeded without a package name:\n%s", out)
+	}
+	if !strings.Contains(out, "no package name given") {
+		t.Errorf("the output does not say the package name is missing:\n%s", out)
+	}
+}
+
+func TestRunToStdout(t *testing.T) {
+	dir := t.TempDir()
+	src := `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`
+	err := ioutil.WriteFile(filepath.Join(dir, "expr.go"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{{"-stdout"}, {"-out", "-"}} {
+		out, err := runCommand(t, append(args, "-srcdir", dir, "-pkg", "expr", "Expr", "ExprConsumer")...)
+		if err != nil {
+			t.Fatalf("%v: %s\n%s", args, err, out)
+		}
+		if !strings.Contains(out, "func (e *Lit) FeedTo(consumer ExprConsumer)") {
+			t.Errorf("%v: unexpected output:\n%s", args, out)
+		}
+		assertOnlyFile(t, dir, "expr.go")
+	}
+}
+
+func TestStdoutConflictsWithOut(t *testing.T) {
+	out, err := runCommand(t, "-stdout", "-out", "expr_gen.go", "-srcdir", t.TempDir(), "-pkg", "expr", "Expr", "ExprConsumer")
+	if err == nil {
+		t.Fatalf("the command succeeded with both -stdout and -out:\n%s", out)
+	}
+	if !strings.Contains(out, "only one of -out and -stdout can be given") {
+		t.Errorf("the output does not name the conflicting flags:\n%s", out)
+	}
+}
+
+func TestPairDirectives(t *testing.T) {
+	pairs, err := pairDirectives(filepath.FromSlash("testdata/pairs/expr.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []irgen.TypeNames{
+		{Composite: "Expr", Consumer: "ExprConsumer"},
+		{Composite: "Pattern", Consumer: "PatternConsumer"},
+	}
+	if fmt.Sprint(pairs) != fmt.Sprint(want) {
+		t.Errorf("got pairs %v, want %v", pairs, want)
+	}
+}
+
+func TestBadPairDirective(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "expr.go")
+	err := ioutil.WriteFile(filename, []byte("package expr\n\n//irgen:pair Expr\ntype Expr interface{}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = pairDirectives(filename)
+	want := "expr.go:3:1: //irgen:pair should name a composite and a consumer type"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestRunWithPairDirectives(t *testing.T) {
+	dir := t.TempDir()
+	src, err := ioutil.ReadFile(filepath.FromSlash("testdata/pairs/expr.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "expr.go"), src, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: This is what go generate does.
+	cmd := exec.Command(os.Args[0], "-out", "pairs_impl.go")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "IRGEN_RUN_MAIN=1", "GOFILE=expr.go", "GOPACKAGE=pairs")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s\n%s", err, out)
+	}
+
+	generated, err := ioutil.ReadFile(filepath.Join(dir, "pairs_impl.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }",
+		"func (p *Wildcard) Match(consumer PatternConsumer) { consumer.Wildcard() }",
+	} {
+		if !bytes.Contains(generated, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, generated)
+		}
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	var buf bytes.Buffer
+	writeDiff(&buf, []byte("a\nb\nc\nd\n"), []byte("a\nx\nc\nd\ne\n"))
+
+	want := "@@ -2 +2 @@\n-b\n+x\n@@ -5 +5 @@\n+e\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got diff\n%s\nwant\n%s", got, want)
+	}
+}
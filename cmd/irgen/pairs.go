@@ -0,0 +1,47 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/szabba/irgen"
+)
+
+const pairDirective = "//irgen:pair"
+
+// pairDirectives reads the composite/consumer pairs named by //irgen:pair
+// directives in the file, in order, eg.
+//
+//	//irgen:pair Expr ExprConsumer
+//
+// A single //go:generate irgen line can then generate the code for all of them.
+func pairDirectives(filename string) ([]irgen.TypeNames, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, nil, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("can't read the pair directives: %w", err)
+	}
+
+	var pairs []irgen.TypeNames
+	for _, group := range f.Comments {
+		for _, comment := range group.List {
+			args, ok := strings.CutPrefix(comment.Text, pairDirective)
+			if !ok || (args != "" && args[0] != ' ' && args[0] != '\t') {
+				continue
+			}
+
+			names := strings.Fields(args)
+			if len(names) != 2 {
+				return nil, fmt.Errorf("%s: %s should name a composite and a consumer type", fset.Position(comment.Pos()), pairDirective)
+			}
+			pairs = append(pairs, irgen.TypeNames{Composite: names[0], Consumer: names[1]})
+		}
+	}
+	return pairs, nil
+}
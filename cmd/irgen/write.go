@@ -0,0 +1,51 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeFile replaces the contents of the file with src, without ever leaving
+// it partially written.
+func writeFile(filename string, src []byte) error {
+	return writeAtomically(filename, func(w io.Writer) error {
+		_, err := w.Write(src)
+		return err
+	})
+}
+
+// writeAtomically has write fill a temporary file next to the named one, which
+// then replaces it. When write fails, the temporary file is removed and the
+// named one is left as it was.
+//
+// NOTE: The temporary file is in the same directory, since renaming across
+// file systems is not atomic -- or not possible at all.
+func writeAtomically(filename string, write func(io.Writer) error) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+
+	err = write(tmp)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+)
+
+// generateConstructors builds a constructor for each variant, returning it as
+// the composite type, or as a pointer to the variant type when concrete is set.
+// With value receivers, the concrete constructors return the variant by value.
+func (gen *generator) generateConstructors(variants []*ast.TypeSpec, concrete bool) ([]ast.Decl, error) {
+	variantNames := make(map[string]bool, len(variants))
+	for _, variant := range variants {
+		variantNames[variant.Name.Name] = true
+	}
+
+	var decls []ast.Decl
+	for _, variant := range variants {
+		name := "Make" + variant.Name.Name
+		result := gen.instantiate(gen.composite.Name.Name)
+		if concrete {
+			name = "New" + variant.Name.Name
+			result = gen.instantiate(variant.Name.Name)
+			if !gen.ValueReceiver {
+				result = &ast.StarExpr{X: result}
+			}
+		}
+
+		if variantNames[name] {
+			return nil, fmt.Errorf("the constructor of variant %s would have the same name as variant %s", variant.Name.Name, name)
+		}
+
+		decls = append(decls, gen.generateConstructor(variant, name, result))
+	}
+	return decls, nil
+}
+
+// generateConstructor builds a function with the given name, creating the
+// variant out of its fields and returning it as the result type, eg.
+//
+//	func MakeLit(n int) Expr { return &Lit{N: n} }
+//	func NewLit(n int) *Lit { return &Lit{N: n} }
+func (gen *generator) generateConstructor(variant *ast.TypeSpec, name string, result ast.Expr) *ast.FuncDecl {
+	var (
+		params []*ast.Field
+		elts   []ast.Expr
+	)
+
+	for _, field := range variant.Type.(*ast.StructType).Fields.List {
+
+		var names []*ast.Ident
+		for _, name := range field.Names {
+
+			param := &ast.Ident{Name: paramName(name.Name)}
+			names = append(names, param)
+
+			elts = append(elts, &ast.KeyValueExpr{
+				Key:   &ast.Ident{Name: name.Name},
+				Value: param,
+			})
+		}
+
+		params = append(params, &ast.Field{Names: names, Type: field.Type})
+	}
+
+	var value ast.Expr = &ast.CompositeLit{Type: gen.instantiate(variant.Name.Name), Elts: elts}
+	if !gen.ValueReceiver {
+		value = &ast.UnaryExpr{Op: token.AND, X: value}
+	}
+
+	return &ast.FuncDecl{
+		Name: &ast.Ident{Name: name},
+		Type: &ast.FuncType{
+			TypeParams: gen.typeParams(),
+			Params:     &ast.FieldList{List: params},
+			Results: &ast.FieldList{
+				List: []*ast.Field{{Type: result}},
+			},
+		},
+		Body: &ast.BlockStmt{
+			List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{value}}},
+		},
+	}
+}
+
+// generateValidatingConstructors builds a constructor for each variant that
+// returns it as the composite type along with an error. The error comes from
+// the validator of the variant, if the source package declares one, eg.
+//
+//	func NewLit(n int) (Expr, error) {
+//		v := &Lit{N: n}
+//		if err := validateLit(v); err != nil {
+//			return nil, err
+//		}
+//		return v, nil
+//	}
+//
+// Without a validator the error is always nil.
+func (gen *generator) generateValidatingConstructors(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	variantNames := make(map[string]bool, len(variants))
+	for _, variant := range variants {
+		variantNames[variant.Name.Name] = true
+	}
+
+	var src strings.Builder
+	for _, variant := range variants {
+		name := "New" + variant.Name.Name
+		if variantNames[name] {
+			return nil, fmt.Errorf("the constructor of variant %s would have the same name as variant %s", variant.Name.Name, name)
+		}
+
+		var (
+			params, elts []string
+			taken        = make(map[string]bool)
+		)
+		for _, field := range gen.variantFields(variant) {
+			param := paramName(field.Name)
+			taken[param] = true
+			params = append(params, param+" "+types.ExprString(field.Type))
+			elts = append(elts, field.Name+": "+param)
+		}
+		lit := gen.variantValue(fmt.Sprintf("%s{%s}", gen.variantType(variant), strings.Join(elts, ", ")))
+
+		fmt.Fprintf(&src, "func %s%s(%s) (%s, error) {\n", name, gen.typeParamsDecl(), strings.Join(params, ", "), gen.compositeType())
+
+		validator, err := gen.findValidator(variant)
+		if err != nil {
+			return nil, err
+		}
+		if validator == "" {
+			fmt.Fprintf(&src, "return %s, nil\n}\n\n", lit)
+			continue
+		}
+
+		v, errName := localName("v", taken), localName("err", taken)
+		fmt.Fprintf(&src, "%s := %s\n", v, lit)
+		fmt.Fprintf(&src, "if %s := %s(%s); %s != nil {\nreturn nil, %s\n}\n", errName, validator, v, errName, errName)
+		fmt.Fprintf(&src, "return %s, nil\n}\n\n", v)
+	}
+
+	return gen.parseDecls(src.String())
+}
+
+// findValidator looks for the function validating a variant in the source
+// package, eg. validateLit for Lit, and returns its name -- or "" when there's
+// none.
+func (gen *generator) findValidator(variant *ast.TypeSpec) (string, error) {
+	name := "validate" + variant.Name.Name
+
+	for _, f := range sortedFiles(gen.pkg) {
+		for _, decl := range f.Decls {
+			fun, ok := decl.(*ast.FuncDecl)
+			if !ok || fun.Recv != nil || fun.Name.Name != name {
+				continue
+			}
+
+			if gen.generatesElsewhere() {
+				return "", gen.errorAt(fun,
+					"validator %s can't be called from package %s",
+					name, gen.outputPackage())
+			}
+			results := fun.Type.Results
+			if fun.Type.Params.NumFields() != 1 || results.NumFields() != 1 || types.ExprString(results.List[0].Type) != "error" {
+				return "", gen.errorAt(fun,
+					"validator %s should take a %s and return an error",
+					name, gen.variantRecv(variant))
+			}
+			return name, nil
+		}
+	}
+	return "", nil
+}
+
+// localName picks a name for a local variable that's not among the taken ones,
+// by appending underscores, and marks it as taken.
+func localName(name string, taken map[string]bool) string {
+	for taken[name] {
+		name += "_"
+	}
+	taken[name] = true
+	return name
+}
+
+// paramName turns an exported field name into a parameter name by lowercasing
+// its leading initialism (N -> n, URLPath -> urlPath). Names that would end up
+// being keywords get an underscore appended.
+func paramName(field string) string {
+	runes := []rune(field)
+
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+
+	// In URLPath the P starts the next word, so it stays upper case.
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper--
+	}
+
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	name := string(runes)
+	if token.Lookup(name).IsKeyword() {
+		name += "_"
+	}
+	return name
+}
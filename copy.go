@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// generateCopy builds a Copy method for each variant, returning a deep copy of
+// it as the composite type. Fields of the composite type (or slices or maps of
+// it) are copied recursively, and so are the composite values behind pointers.
+// Other slices and maps get copied shallowly and everything else is copied as
+// is.
+//
+// Like with Equal, the recursion goes through a helper function that checks
+// for the method dynamically. Values without it are shared by the copies.
+func (gen *generator) generateCopy(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	helper := "copy" + gen.composite.Name.Name
+	sliceHelper := helper + "s"
+	mapHelper := helper + "Map"
+	ptrHelper := helper + "Ptr"
+	ptrSliceHelper := helper + "Ptrs"
+	recv := gen.receiverName()
+
+	var (
+		src                                       strings.Builder
+		needsSlice, needsMap, needsPtr, needsPtrs bool
+	)
+
+	fmt.Fprintf(&src, "func %s%s(e %s) %s {\n", helper, gen.typeParamsDecl(), composite, composite)
+	fmt.Fprintf(&src, "if c, ok := e.(interface{ Copy() %s }); ok {\nreturn c.Copy()\n}\n", composite)
+	fmt.Fprintf(&src, "return e\n}\n\n")
+
+	for _, variant := range variants {
+		var elts []string
+		for _, field := range gen.variantFields(variant) {
+			value := recv + "." + field.Name
+
+			switch field.Kind {
+			case compositeField:
+				value = fmt.Sprintf("%s(%s)", helper, value)
+			case compositeSliceField:
+				needsSlice = true
+				value = fmt.Sprintf("%s(%s)", sliceHelper, value)
+			case sliceField:
+				value = fmt.Sprintf("append(%s(nil), %s...)", types.ExprString(field.Type), value)
+			case compositeMapField:
+				needsMap = true
+				value = fmt.Sprintf("%s(%s)", mapHelper, value)
+			case mapField:
+				value = fmt.Sprintf("%s(%s)", gen.qualified("maps", "Clone"), value)
+			case compositePointerField:
+				needsPtr = true
+				value = fmt.Sprintf("%s(%s)", ptrHelper, value)
+			case compositePointerSliceField:
+				needsPtr, needsPtrs = true, true
+				value = fmt.Sprintf("%s(%s)", ptrSliceHelper, value)
+			}
+
+			elts = append(elts, field.Name+": "+value)
+		}
+
+		lit := fmt.Sprintf("%s{%s}", gen.variantType(variant), strings.Join(elts, ", "))
+		fmt.Fprintf(&src, "func (%s %s) Copy() %s {\n", recv, gen.variantRecv(variant), composite)
+		fmt.Fprintf(&src, "return %s\n}\n\n", gen.variantValue(lit))
+	}
+
+	if needsSlice {
+		fmt.Fprintf(&src, "func %s%s(es []%s) []%s {\n", sliceHelper, gen.typeParamsDecl(), composite, composite)
+		fmt.Fprintf(&src, "if es == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "copies := make([]%s, len(es))\n", composite)
+		fmt.Fprintf(&src, "for i, e := range es {\ncopies[i] = %s(e)\n}\n", helper)
+		fmt.Fprintf(&src, "return copies\n}\n\n")
+	}
+
+	if needsMap {
+		typeParams, key := gen.mapHelperTypeParams()
+		fmt.Fprintf(&src, "func %s%s(es map[%s]%s) map[%s]%s {\n", mapHelper, typeParams, key, composite, key, composite)
+		fmt.Fprintf(&src, "if es == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "copies := make(map[%s]%s, len(es))\n", key, composite)
+		fmt.Fprintf(&src, "for key, e := range es {\ncopies[key] = %s(e)\n}\n", helper)
+		fmt.Fprintf(&src, "return copies\n}\n\n")
+	}
+
+	if needsPtr {
+		fmt.Fprintf(&src, "func %s%s(e *%s) *%s {\n", ptrHelper, gen.typeParamsDecl(), composite, composite)
+		fmt.Fprintf(&src, "if e == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "c := %s(*e)\nreturn &c\n}\n\n", helper)
+	}
+
+	if needsPtrs {
+		fmt.Fprintf(&src, "func %s%s(es []*%s) []*%s {\n", ptrSliceHelper, gen.typeParamsDecl(), composite, composite)
+		fmt.Fprintf(&src, "if es == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "copies := make([]*%s, len(es))\n", composite)
+		fmt.Fprintf(&src, "for i, e := range es {\ncopies[i] = %s(e)\n}\n", ptrHelper)
+		fmt.Fprintf(&src, "return copies\n}\n")
+	}
+
+	return gen.parseDecls(src.String())
+}
@@ -0,0 +1,92 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"strings"
+)
+
+const directivePrefix = "//irgen:"
+
+// The directives irgen understands, by name.
+var knownDirectives = map[string]bool{
+	"tag":         true,
+	"field":       true,
+	"passthrough": true,
+	// NOTE: The command reads this one, to tell which pairs to generate the
+	// code for.
+	"pair": true,
+	// NOTE: irgen generates this one, and the output is often parsed along
+	// with the source.
+	"variants": true,
+}
+
+// directive splits an //irgen:name comment into the name and the rest of the
+// line. Other comments are not directives.
+func directive(comment *ast.Comment) (name, args string, ok bool) {
+	if !strings.HasPrefix(comment.Text, directivePrefix) {
+		return "", "", false
+	}
+
+	line := strings.TrimPrefix(comment.Text, directivePrefix)
+	name, args, _ = strings.Cut(line, " ")
+	return name, strings.TrimSpace(args), true
+}
+
+// directives lists the irgen directives found in the comment groups, in order.
+func directives(groups ...*ast.CommentGroup) []*ast.Comment {
+	var found []*ast.Comment
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			if _, _, ok := directive(comment); ok {
+				found = append(found, comment)
+			}
+		}
+	}
+	return found
+}
+
+// checkDirectives makes sure all the irgen directives in the source package
+// are known ones, so that a typo doesn't get silently ignored.
+func (gen *generator) checkDirectives() error {
+	for _, f := range sortedFiles(gen.pkg) {
+		for _, group := range f.Comments {
+			for _, comment := range directives(group) {
+				name, _, _ := directive(comment)
+				if !knownDirectives[name] {
+					return gen.errorAt(comment, "unknown directive %s%s", directivePrefix, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// variantManifest builds the doc comment of the first variant declaration of a
+// composite, with an //irgen:variants directive listing the variant names in
+// order appended, eg.
+//
+//	//irgen:variants Lit Var Add
+//
+// Tools like linters can then tell whether a switch on the composite is
+// exhaustive without parsing the consumer.
+func variantManifest(doc *ast.CommentGroup, variants []*ast.TypeSpec) *ast.CommentGroup {
+	names := make([]string, len(variants))
+	for i, variant := range variants {
+		names[i] = variant.Name.Name
+	}
+
+	var list []*ast.Comment
+	if doc != nil {
+		list = append(list, doc.List...)
+		list = append(list, &ast.Comment{Text: "//"})
+	}
+	list = append(list, &ast.Comment{Text: directivePrefix + "variants " + strings.Join(names, " ")})
+	return &ast.CommentGroup{List: list}
+}
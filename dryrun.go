@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"os"
+	"strings"
+)
+
+// summaryLine describes what gets generated for the current pair, eg.
+//
+//	Expr/ExprConsumer: Lit, Var, Add (FeedTo)
+func (gen *generator) summaryLine(variants []*ast.TypeSpec) string {
+	names := make([]string, len(variants))
+	for i, variant := range variants {
+		names[i] = variant.Name.Name
+	}
+
+	list := strings.Join(names, ", ")
+	if len(names) == 0 {
+		list = "no variants"
+	}
+
+	return fmt.Sprintf("%s/%s: %s (%s)",
+		gen.TypeNames.Composite, gen.TypeNames.Consumer,
+		list, gen.destructuring.Names[0].Name)
+}
+
+// summarize writes out the summary of a dry run.
+func (gen *generator) summarize() error {
+	var out io.Writer = os.Stderr
+	if gen.Summary != nil {
+		out = gen.Summary
+	}
+
+	for _, line := range gen.summary {
+		_, err := fmt.Fprintln(out, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
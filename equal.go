@@ -0,0 +1,108 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateEqual builds an Equal method for each variant, comparing it
+// structurally with another value of the composite type. Fields of the
+// composite type (or slices or maps of it) are compared recursively, and so are
+// the composite values behind pointers. Other slices and maps are compared
+// element-wise and everything else with ==, or with reflect.DeepEqual for
+// types that == doesn't work on, like funcs and nested slices. Note that funcs
+// are only deeply equal when both are nil.
+//
+// Since the composite interface does not have to declare Equal, the recursion
+// goes through a helper function that checks for the method dynamically.
+func (gen *generator) generateEqual(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	helper := "equal" + gen.composite.Name.Name
+	ptrHelper := helper + "Ptr"
+	recv := gen.receiverName()
+
+	differ := func(this, that string, typ ast.Expr) string {
+		if gen.comparable(typ) {
+			return this + " != " + that
+		}
+		return fmt.Sprintf("!%s(%s, %s)", gen.qualified("reflect", "DeepEqual"), this, that)
+	}
+
+	var (
+		src      strings.Builder
+		needsPtr bool
+	)
+
+	fmt.Fprintf(&src, "func %s%s(a, b %s) bool {\n", helper, gen.typeParamsDecl(), composite)
+	fmt.Fprintf(&src, "if a == nil || b == nil {\nreturn a == nil && b == nil\n}\n")
+	fmt.Fprintf(&src, "eq, ok := a.(interface{ Equal(%s) bool })\n", composite)
+	fmt.Fprintf(&src, "return ok && eq.Equal(b)\n}\n\n")
+
+	for _, variant := range variants {
+		typ := gen.variantRecv(variant)
+
+		fmt.Fprintf(&src, "func (%s %s) Equal(other %s) bool {\n", recv, typ, composite)
+		fields := gen.variantFields(variant)
+		if len(fields) == 0 {
+			fmt.Fprintf(&src, "_, ok := other.(%s)\nreturn ok\n}\n\n", typ)
+			continue
+		}
+		fmt.Fprintf(&src, "that, ok := other.(%s)\nif !ok {\nreturn false\n}\n", typ)
+
+		for _, field := range fields {
+			this, that := recv+"."+field.Name, "that."+field.Name
+
+			switch field.Kind {
+			case compositeField:
+				fmt.Fprintf(&src, "if !%s(%s, %s) {\nreturn false\n}\n", helper, this, that)
+
+			case compositePointerField:
+				needsPtr = true
+				fmt.Fprintf(&src, "if !%s(%s, %s) {\nreturn false\n}\n", ptrHelper, this, that)
+
+			case compositeSliceField, compositePointerSliceField, sliceField:
+				fmt.Fprintf(&src, "if len(%s) != len(%s) {\nreturn false\n}\n", this, that)
+				fmt.Fprintf(&src, "for i := range %s {\n", this)
+				switch field.Kind {
+				case compositeSliceField:
+					fmt.Fprintf(&src, "if !%s(%s[i], %s[i]) {\nreturn false\n}\n", helper, this, that)
+				case compositePointerSliceField:
+					needsPtr = true
+					fmt.Fprintf(&src, "if !%s(%s[i], %s[i]) {\nreturn false\n}\n", ptrHelper, this, that)
+				default:
+					fmt.Fprintf(&src, "if %s {\nreturn false\n}\n", differ(this+"[i]", that+"[i]", field.Type.(*ast.ArrayType).Elt))
+				}
+				fmt.Fprintf(&src, "}\n")
+
+			case compositeMapField, mapField:
+				fmt.Fprintf(&src, "if len(%s) != len(%s) {\nreturn false\n}\n", this, that)
+				fmt.Fprintf(&src, "for key, value := range %s {\n", this)
+				fmt.Fprintf(&src, "thatValue, ok := %s[key]\n", that)
+				if field.Kind == compositeMapField {
+					fmt.Fprintf(&src, "if !ok || !%s(value, thatValue) {\nreturn false\n}\n", helper)
+				} else {
+					fmt.Fprintf(&src, "if !ok || %s {\nreturn false\n}\n", differ("value", "thatValue", field.Type.(*ast.MapType).Value))
+				}
+				fmt.Fprintf(&src, "}\n")
+
+			default:
+				fmt.Fprintf(&src, "if %s {\nreturn false\n}\n", differ(this, that, field.Type))
+			}
+		}
+
+		fmt.Fprintf(&src, "return true\n}\n\n")
+	}
+
+	if needsPtr {
+		fmt.Fprintf(&src, "func %s%s(a, b *%s) bool {\n", ptrHelper, gen.typeParamsDecl(), composite)
+		fmt.Fprintf(&src, "if a == nil || b == nil {\nreturn a == b\n}\n")
+		fmt.Fprintf(&src, "return %s(*a, *b)\n}\n", helper)
+	}
+
+	return gen.parseDecls(src.String())
+}
@@ -0,0 +1,45 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen_test
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/szabba/irgen"
+)
+
+func ExampleConfig_Generate() {
+	config := irgen.Config{
+		Directory:   filepath.FromSlash("internal/test_cases/types"),
+		PackageName: "types",
+	}
+	config.TypeNames.Composite = "Type"
+	config.TypeNames.Consumer = "TypeConsumer"
+
+	err := config.Generate(os.Stdout)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// // Code generated by irgen; DO NOT EDIT.
+	//
+	// package types
+	//
+	// type Named struct {
+	// 	Name string
+	// 	Args []Type
+	// }
+	//
+	// func (t *Named) FeedTo(consumer TypeConsumer) { consumer.Named(t.Name, t.Args) }
+	//
+	// type Function struct {
+	// 	Arg, Output Type
+	// }
+	//
+	// func (t *Function) FeedTo(consumer TypeConsumer) { consumer.Function(t.Arg, t.Output) }
+}
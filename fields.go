@@ -0,0 +1,242 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// How the code generated for recursive operations (like Equal) has to treat
+// a variant field.
+type fieldKind int
+
+const (
+	// A field that's handled as a whole, with == and the like.
+	leafField fieldKind = iota
+	// A field of the composite type, which the operation recurses into.
+	compositeField
+	// A slice of the composite type, handled element-wise with recursion.
+	compositeSliceField
+	// A slice of anything else, handled element-wise.
+	sliceField
+	// A map with values of the composite type, handled value-wise with
+	// recursion.
+	compositeMapField
+	// A map of anything else, handled entry-wise.
+	mapField
+	// A pointer to the composite type, which the operation recurses into
+	// when it's not nil. Features that don't know about pointers handle it
+	// as a leaf.
+	compositePointerField
+	// A slice of pointers to the composite type, handled element-wise like
+	// compositePointerField.
+	compositePointerSliceField
+)
+
+// A named field of a variant.
+type variantField struct {
+	Name string
+	Type ast.Expr
+	Kind fieldKind
+	// The struct tag literal, if the field has one.
+	Tag *ast.BasicLit
+}
+
+// variantFields lists the fields of a generated variant type, in order.
+func (gen *generator) variantFields(variant *ast.TypeSpec) []variantField {
+	var fields []variantField
+	for _, field := range variant.Type.(*ast.StructType).Fields.List {
+		for _, name := range field.Names {
+			fields = append(fields, variantField{
+				Name: name.Name,
+				Type: field.Type,
+				Kind: gen.classifyField(field.Type),
+				Tag:  field.Tag,
+			})
+		}
+	}
+	return fields
+}
+
+func (gen *generator) classifyField(typ ast.Expr) fieldKind {
+	if gen.isComposite(typ) {
+		return compositeField
+	}
+	if gen.isCompositePointer(typ) {
+		return compositePointerField
+	}
+
+	if slice, ok := typ.(*ast.ArrayType); ok && slice.Len == nil {
+		if gen.isComposite(slice.Elt) {
+			return compositeSliceField
+		}
+		if gen.isCompositePointer(slice.Elt) {
+			return compositePointerSliceField
+		}
+		return sliceField
+	}
+
+	if m, ok := typ.(*ast.MapType); ok {
+		if gen.isComposite(m.Value) {
+			return compositeMapField
+		}
+		return mapField
+	}
+
+	// NOTE: Variadic parameters become slices in the variants.
+	if variadic, ok := typ.(*ast.Ellipsis); ok {
+		if gen.isComposite(variadic.Elt) {
+			return compositeSliceField
+		}
+		if gen.isCompositePointer(variadic.Elt) {
+			return compositePointerSliceField
+		}
+		return sliceField
+	}
+
+	// NOTE: Anything else is opaque, like token.Position or a struct of the
+	// source package -- even one holding composite values.
+	return leafField
+}
+
+// isComposite tells whether the type expression denotes the composite type.
+func (gen *generator) isComposite(typ ast.Expr) bool {
+	// NOTE: This gets called for every field by every feature, so the common
+	// case skips rendering the types.
+	if ident, ok := typ.(*ast.Ident); ok && gen.composite.TypeParams == nil {
+		return ident.Name == gen.composite.Name.Name
+	}
+	return types.ExprString(typ) == gen.compositeType()
+}
+
+// isCompositePointer tells whether the type expression denotes a pointer to the
+// composite type, eg. *Expr.
+func (gen *generator) isCompositePointer(typ ast.Expr) bool {
+	star, ok := typ.(*ast.StarExpr)
+	return ok && gen.isComposite(star.X)
+}
+
+// compositeType is the composite type as it's written in the generated code,
+// instantiated with its type parameters if it has any.
+func (gen *generator) compositeType() string {
+	return types.ExprString(gen.instantiate(gen.composite.Name.Name))
+}
+
+// variantType is a variant type as it's written in the generated code.
+func (gen *generator) variantType(variant *ast.TypeSpec) string {
+	return types.ExprString(gen.instantiate(variant.Name.Name))
+}
+
+// variantRecv is the receiver type of the methods generated for a variant. It's
+// a pointer to the variant, unless the variants get value receivers.
+func (gen *generator) variantRecv(variant *ast.TypeSpec) string {
+	if gen.ValueReceiver {
+		return gen.variantType(variant)
+	}
+	return "*" + gen.variantType(variant)
+}
+
+// variantValue turns a composite literal of a variant into a value the
+// methods are defined on -- taking its address, unless the variants get value
+// receivers.
+func (gen *generator) variantValue(lit string) string {
+	if gen.ValueReceiver {
+		return lit
+	}
+	return "&" + lit
+}
+
+// typeParamsDecl is the type parameter list to put after a generic function
+// name, or "" when the composite is not generic.
+func (gen *generator) typeParamsDecl() string {
+	if gen.composite.TypeParams == nil {
+		return ""
+	}
+	return "[" + typeParamsString(gen.composite.TypeParams) + "]"
+}
+
+// mapHelperTypeParams is the type parameter list of a helper function taking a
+// map of composite values, with a key type parameter besides the ones of the
+// composite. The key type parameter is returned too.
+func (gen *generator) mapHelperTypeParams() (string, string) {
+	key := "K"
+	for gen.isTypeParam(key) {
+		key += "_"
+	}
+
+	if gen.composite.TypeParams == nil {
+		return "[" + key + " comparable]", key
+	}
+	return "[" + typeParamsString(gen.composite.TypeParams) + ", " + key + " comparable]", key
+}
+
+// comparable tells whether values of the type can be compared with ==, as far
+// as the source package tells. Types declared in other packages are assumed to
+// be, while type parameters only are when constrained to be comparable.
+func (gen *generator) comparable(typ ast.Expr) bool {
+	return gen.comparableSeen(typ, make(map[string]bool))
+}
+
+func (gen *generator) comparableSeen(typ ast.Expr, seen map[string]bool) bool {
+	switch typ := typ.(type) {
+	case *ast.FuncType, *ast.MapType, *ast.Ellipsis:
+		return false
+	case *ast.ArrayType:
+		return typ.Len != nil && gen.comparableSeen(typ.Elt, seen)
+	case *ast.ParenExpr:
+		return gen.comparableSeen(typ.X, seen)
+	case *ast.IndexExpr:
+		return gen.comparableSeen(typ.X, seen)
+	case *ast.IndexListExpr:
+		return gen.comparableSeen(typ.X, seen)
+	case *ast.StructType:
+		for _, field := range typ.Fields.List {
+			if !gen.comparableSeen(field.Type, seen) {
+				return false
+			}
+		}
+		return true
+	case *ast.Ident:
+		if gen.isTypeParam(typ.Name) {
+			return gen.comparableConstraint(gen.typeParamConstraint(typ.Name), make(map[string]bool))
+		}
+		if seen[typ.Name] {
+			return true
+		}
+		seen[typ.Name] = true
+		spec, err := typeSpecNamed(gen.pkg, typ.Name)
+		if err != nil {
+			return true
+		}
+		return gen.comparableSeen(spec.Type, seen)
+	default:
+		return true
+	}
+}
+
+// comparableConstraint tells whether a type constraint is comparable, or an
+// interface embedding it (possibly through interfaces of the source package).
+func (gen *generator) comparableConstraint(constraint ast.Expr, seen map[string]bool) bool {
+	switch constraint := constraint.(type) {
+	case *ast.Ident:
+		if constraint.Name == "comparable" {
+			return true
+		}
+		if seen[constraint.Name] {
+			return false
+		}
+		seen[constraint.Name] = true
+		spec, err := typeSpecNamed(gen.pkg, constraint.Name)
+		return err == nil && gen.comparableConstraint(spec.Type, seen)
+	case *ast.InterfaceType:
+		for _, elem := range constraint.Methods.List {
+			if len(elem.Names) == 0 && gen.comparableConstraint(elem.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
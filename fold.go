@@ -0,0 +1,155 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// generateFold builds a function folding a composite value into a result, with
+// a struct of handler functions standing in for the consumer, eg.
+//
+//	type ExprHandlers[R any] struct {
+//		Lit func(n int) R
+//		Add func(left, right R) R
+//	}
+//
+//	func FoldExpr[R any](e Expr, h ExprHandlers[R]) R
+//
+// Fields of the composite type (or slices of it) get folded first, so the
+// handlers receive their results.
+func (gen *generator) generateFold() ([]ast.Decl, error) {
+	destructuring := gen.destructuring.Type.(*ast.FuncType)
+	if destructuring.Results.NumFields() > 0 {
+		return nil, gen.errorAt(gen.destructuring,
+			"composite method %s has results, so there's no way to fold %s",
+			gen.destructuring.Names[0].Name, gen.TypeNames.Composite)
+	}
+
+	name := gen.composite.Name.Name
+	composite := gen.compositeType()
+	result := gen.resultTypeParam()
+
+	typeParams := result + " any"
+	typeArgs := []string{result}
+	if gen.composite.TypeParams != nil {
+		typeParams = typeParamsString(gen.composite.TypeParams) + ", " + typeParams
+
+		typeArgs = nil
+		for _, arg := range paramNames(gen.composite.TypeParams) {
+			typeArgs = append(typeArgs, types.ExprString(arg))
+		}
+		typeArgs = append(typeArgs, result)
+	}
+
+	handlersName := name + "Handlers"
+	handlers := handlersName + "[" + strings.Join(typeArgs, ", ") + "]"
+	folderName := paramName(name) + "Folder"
+	folder := folderName + "[" + strings.Join(typeArgs, ", ") + "]"
+	fold := "Fold" + name
+	sliceHelper := "fold" + name + "s"
+
+	var (
+		src        strings.Builder
+		fields     []string
+		methods    strings.Builder
+		needsSlice bool
+	)
+
+	for _, method := range gen.variants {
+		variant := method.Names[0].Name
+
+		var (
+			params        []string
+			handlerParams []string
+			args          []string
+		)
+		for _, field := range method.Type.(*ast.FuncType).Params.List {
+			typ := types.ExprString(field.Type)
+			kind := gen.classifyField(field.Type)
+			switch kind {
+			case compositeField:
+				typ = result
+			case compositeSliceField:
+				needsSlice = true
+				typ = "[]" + result
+			case sliceField:
+				if variadic, ok := field.Type.(*ast.Ellipsis); ok {
+					typ = "[]" + types.ExprString(variadic.Elt)
+				}
+			}
+
+			var names, handlerNames []string
+			for _, param := range field.Names {
+				names = append(names, param.Name)
+				handlerNames = append(handlerNames, paramName(param.Name))
+
+				switch kind {
+				case compositeField:
+					args = append(args, fold+"("+param.Name+", f.handlers)")
+				case compositeSliceField:
+					args = append(args, sliceHelper+"("+param.Name+", f.handlers)")
+				default:
+					args = append(args, param.Name)
+				}
+			}
+
+			params = append(params, strings.Join(names, ", ")+" "+types.ExprString(field.Type))
+			handlerParams = append(handlerParams, strings.Join(handlerNames, ", ")+" "+typ)
+		}
+
+		fields = append(fields, fmt.Sprintf("%s func(%s) %s", variant, strings.Join(handlerParams, ", "), result))
+
+		fmt.Fprintf(&methods, "func (f *%s) %s(%s) {\n", folder, variant, strings.Join(params, ", "))
+		fmt.Fprintf(&methods, "f.result = f.handlers.%s(%s)\n}\n\n", variant, strings.Join(args, ", "))
+	}
+
+	fmt.Fprintf(&src, "type %s[%s] struct {\n%s\n}\n\n", handlersName, typeParams, strings.Join(fields, "\n"))
+
+	fmt.Fprintf(&src, "func %s[%s](e %s, h %s) %s {\n", fold, typeParams, composite, handlers, result)
+	fmt.Fprintf(&src, "f := &%s{handlers: h}\n", folder)
+	if gen.consumerByPointer() {
+		fmt.Fprintf(&src, "var consumer %s = f\n", types.ExprString(gen.instantiate(gen.TypeNames.Consumer)))
+		fmt.Fprintf(&src, "e.%s(&consumer)\n", gen.destructuring.Names[0].Name)
+	} else {
+		fmt.Fprintf(&src, "e.%s(f)\n", gen.destructuring.Names[0].Name)
+	}
+	fmt.Fprintf(&src, "return f.result\n}\n\n")
+
+	fmt.Fprintf(&src, "type %s[%s] struct {\nhandlers %s\nresult %s\n}\n\n", folderName, typeParams, handlers, result)
+	src.WriteString(methods.String())
+
+	if needsSlice {
+		fmt.Fprintf(&src, "func %s[%s](es []%s, h %s) []%s {\n", sliceHelper, typeParams, composite, handlers, result)
+		fmt.Fprintf(&src, "rs := make([]%s, len(es))\n", result)
+		fmt.Fprintf(&src, "for i, e := range es {\nrs[i] = %s(e, h)\n}\n", fold)
+		fmt.Fprintf(&src, "return rs\n}\n")
+	}
+
+	return gen.parseDecls(src.String())
+}
+
+// resultTypeParam picks the name of the type parameter for the result of
+// a fold, so that it doesn't clash with the composite's type parameters or with
+// the consumer method parameters the folder methods declare.
+func (gen *generator) resultTypeParam() string {
+	params := make(map[string]bool)
+	for _, method := range gen.variants {
+		for _, field := range method.Type.(*ast.FuncType).Params.List {
+			for _, name := range field.Names {
+				params[name.Name] = true
+			}
+		}
+	}
+
+	name := "R"
+	for gen.isTypeParam(name) || params[name] {
+		name += "_"
+	}
+	return name
+}
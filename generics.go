@@ -0,0 +1,102 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// checkTypeParams makes sure the composite and the consumer declare the same
+// type parameters. The variants get the very same ones, and both the field
+// types and the destructuring method signature refer to them by name.
+func (gen *generator) checkTypeParams() error {
+	comp := typeParamsString(gen.composite.TypeParams)
+	cons := typeParamsString(gen.consumer.TypeParams)
+
+	if comp != cons {
+		return gen.errorAt(gen.consumer,
+			"composite type %s has type parameters [%s], but consumer type %s has [%s] (they should be the same)",
+			gen.TypeNames.Composite, comp, gen.TypeNames.Consumer, cons)
+	}
+	return nil
+}
+
+func typeParamsString(params *ast.FieldList) string {
+	if params == nil {
+		return ""
+	}
+
+	var groups []string
+	for _, field := range params.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		groups = append(groups, strings.Join(names, ", ")+" "+types.ExprString(field.Type))
+	}
+	return strings.Join(groups, ", ")
+}
+
+// typeParams returns a copy of the type parameters the generated types and
+// functions should declare, or nil when the composite is not generic.
+func (gen *generator) typeParams() *ast.FieldList {
+	return copyFieldList(gen.composite.TypeParams)
+}
+
+// isTypeParam tells whether the name is one of the type parameters of the
+// composite.
+func (gen *generator) isTypeParam(name string) bool {
+	if gen.composite == nil || gen.composite.TypeParams == nil {
+		return false
+	}
+
+	for _, field := range gen.composite.TypeParams.List {
+		for _, param := range field.Names {
+			if param.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// typeParamConstraint is the constraint of a type parameter of the composite.
+func (gen *generator) typeParamConstraint(name string) ast.Expr {
+	for _, field := range gen.composite.TypeParams.List {
+		for _, param := range field.Names {
+			if param.Name == name {
+				return field.Type
+			}
+		}
+	}
+	return nil
+}
+
+// instantiate refers to a generic type declared with the same type parameters
+// as the composite, eg. Lit[T]. When the composite is not generic, that's
+// just the type name.
+func (gen *generator) instantiate(name string) ast.Expr {
+	typ := &ast.Ident{Name: name}
+
+	var args []ast.Expr
+	if gen.composite.TypeParams != nil {
+		for _, field := range gen.composite.TypeParams.List {
+			for _, param := range field.Names {
+				args = append(args, &ast.Ident{Name: param.Name})
+			}
+		}
+	}
+
+	switch len(args) {
+	case 0:
+		return typ
+	case 1:
+		return &ast.IndexExpr{X: typ, Index: args[0]}
+	default:
+		return &ast.IndexListExpr{X: typ, Indices: args}
+	}
+}
@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"errors"
+	"go/ast"
+	"go/types"
+)
+
+// checkHandWrittenVariants makes sure the source package declares the variant
+// types, when only their methods get generated. Each has to be a struct with
+// (at least) the fields the variant would have, of the same types. All the
+// mismatches are reported together.
+func (gen *generator) checkHandWrittenVariants(variants []*ast.TypeSpec) error {
+	if gen.generatesElsewhere() {
+		return gen.errorAt(gen.composite,
+			"only the methods of the variants of %s can't be generated in package %s, since they have to go along with the types",
+			gen.TypeNames.Composite, gen.outputPackage())
+	}
+
+	var errs []error
+	for i, variant := range variants {
+		err := gen.checkHandWrittenVariant(variant, gen.variants[i])
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (gen *generator) checkHandWrittenVariant(variant *ast.TypeSpec, method *ast.Field) error {
+	name := variant.Name.Name
+	consumerMethod := method.Names[0].Name
+
+	spec, err := typeSpecNamed(gen.pkg, name)
+	if err != nil {
+		return gen.errorAt(method,
+			"consumer method %s needs a struct %s in package %s, since only the methods of the variants get generated: %w",
+			consumerMethod, name, gen.PackageName, err)
+	}
+
+	shape, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return gen.errorAt(spec, "type %s is not a struct, but consumer method %s needs it to be one", name, consumerMethod)
+	}
+	if spec.TypeParams.NumFields() != variant.TypeParams.NumFields() {
+		return gen.errorAt(spec, "struct %s has %d type parameters, but the composite %s has %d",
+			name, spec.TypeParams.NumFields(), gen.TypeNames.Composite, variant.TypeParams.NumFields())
+	}
+
+	declared := make(map[string]ast.Expr)
+	for _, field := range shape.Fields.List {
+		for _, fieldName := range field.Names {
+			declared[fieldName.Name] = field.Type
+		}
+	}
+
+	var errs []error
+	for _, field := range gen.variantFields(variant) {
+		typ, ok := declared[field.Name]
+		switch {
+		case !ok:
+			errs = append(errs, gen.errorAt(spec, "struct %s has no field %s, which consumer method %s needs", name, field.Name, consumerMethod))
+		case types.ExprString(typ) != types.ExprString(field.Type):
+			errs = append(errs, gen.errorAt(typ, "field %s of struct %s has type %s, but consumer method %s needs %s",
+				field.Name, name, types.ExprString(typ), consumerMethod, types.ExprString(field.Type)))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,144 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateHash builds a Hash method for each variant, computing an FNV-1a
+// hash of the variant name and its fields. Fields of the composite type (or
+// pointers, slices or maps of it) contribute the hash of the values they hold.
+// Slices are hashed element-wise and maps entry-wise, in a way that doesn't
+// depend on the iteration order. Basic types are hashed by their bytes and
+// everything else by how fmt prints it with %#v.
+//
+// Values that are Equal hash equally, except for floating-point zeros of
+// different signs.
+func (gen *generator) generateHash(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	helper := "hash" + gen.composite.Name.Name
+	ptrHelper := helper + "Ptr"
+	recv := gen.receiverName()
+
+	newHash := gen.qualified("hash/fnv", "New64a")
+	write := gen.qualified("encoding/binary", "Write")
+	order := gen.qualified("encoding/binary", "LittleEndian")
+
+	var needsPtr bool
+
+	writeUint := func(src *strings.Builder, h, value string) {
+		fmt.Fprintf(src, "%s(%s, %s, %s)\n", write, h, order, value)
+	}
+
+	writeValue := func(src *strings.Builder, h, value string, typ ast.Expr, kind fieldKind) {
+		switch kind {
+		case compositeField:
+			writeUint(src, h, fmt.Sprintf("%s(%s)", helper, value))
+		case compositePointerField:
+			needsPtr = true
+			writeUint(src, h, fmt.Sprintf("%s(%s)", ptrHelper, value))
+		default:
+			gen.writeHashLeaf(src, h, value, typ)
+		}
+	}
+
+	var src strings.Builder
+
+	fmt.Fprintf(&src, "func %s%s(e %s) uint64 {\n", helper, gen.typeParamsDecl(), composite)
+	fmt.Fprintf(&src, "hasher, ok := e.(interface{ Hash() uint64 })\n")
+	fmt.Fprintf(&src, "if !ok {\nreturn 0\n}\n")
+	fmt.Fprintf(&src, "return hasher.Hash()\n}\n\n")
+
+	for _, variant := range variants {
+		fmt.Fprintf(&src, "func (%s %s) Hash() uint64 {\n", recv, gen.variantRecv(variant))
+		fmt.Fprintf(&src, "h := %s()\n", newHash)
+		fmt.Fprintf(&src, "h.Write([]byte(%q))\n", variant.Name.Name)
+
+		declaredSum := false
+		for _, field := range gen.variantFields(variant) {
+			value := recv + "." + field.Name
+
+			switch field.Kind {
+			case compositeSliceField, compositePointerSliceField, sliceField:
+				elemKind := leafField
+				switch field.Kind {
+				case compositeSliceField:
+					elemKind = compositeField
+				case compositePointerSliceField:
+					elemKind = compositePointerField
+				}
+				writeUint(&src, "h", fmt.Sprintf("uint64(len(%s))", value))
+				fmt.Fprintf(&src, "for _, item := range %s {\n", value)
+				writeValue(&src, "h", "item", field.Type.(*ast.ArrayType).Elt, elemKind)
+				fmt.Fprintf(&src, "}\n")
+
+			case compositeMapField, mapField:
+				typ := field.Type.(*ast.MapType)
+				elemKind := leafField
+				if field.Kind == compositeMapField {
+					elemKind = compositeField
+				}
+				if declaredSum {
+					fmt.Fprintf(&src, "sum = 0\n")
+				} else {
+					fmt.Fprintf(&src, "var sum uint64\n")
+					declaredSum = true
+				}
+				fmt.Fprintf(&src, "for key, value := range %s {\n", value)
+				fmt.Fprintf(&src, "entry := %s()\n", newHash)
+				writeValue(&src, "entry", "key", typ.Key, leafField)
+				writeValue(&src, "entry", "value", typ.Value, elemKind)
+				fmt.Fprintf(&src, "sum += entry.Sum64()\n}\n")
+				writeUint(&src, "h", fmt.Sprintf("uint64(len(%s))", value))
+				writeUint(&src, "h", "sum")
+
+			default:
+				writeValue(&src, "h", value, field.Type, field.Kind)
+			}
+		}
+
+		fmt.Fprintf(&src, "return h.Sum64()\n}\n\n")
+	}
+
+	if needsPtr {
+		fmt.Fprintf(&src, "func %s%s(e *%s) uint64 {\n", ptrHelper, gen.typeParamsDecl(), composite)
+		fmt.Fprintf(&src, "if e == nil {\nreturn 0\n}\n")
+		fmt.Fprintf(&src, "return %s(*e)\n}\n", helper)
+	}
+
+	return gen.parseDecls(src.String())
+}
+
+// writeHashLeaf writes the code feeding a value of a type irgen doesn't
+// recurse into to the hash h.
+func (gen *generator) writeHashLeaf(src *strings.Builder, h, value string, typ ast.Expr) {
+	write := gen.qualified("encoding/binary", "Write")
+	order := gen.qualified("encoding/binary", "LittleEndian")
+
+	name := ""
+	if ident, ok := typ.(*ast.Ident); ok && !gen.declared(ident.Name) && !gen.isTypeParam(ident.Name) {
+		name = ident.Name
+	}
+
+	// NOTE: binary.Write only takes fixed-size values, so the ones whose size
+	// depends on the platform get widened.
+	switch name {
+	case "bool", "int8", "int16", "int32", "int64", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "complex64", "complex128", "byte", "rune":
+		fmt.Fprintf(src, "%s(%s, %s, %s)\n", write, h, order, value)
+	case "int":
+		fmt.Fprintf(src, "%s(%s, %s, int64(%s))\n", write, h, order, value)
+	case "uint", "uintptr":
+		fmt.Fprintf(src, "%s(%s, %s, uint64(%s))\n", write, h, order, value)
+	case "string":
+		fmt.Fprintf(src, "%s(%s, %s, uint64(len(%s)))\n", write, h, order, value)
+		fmt.Fprintf(src, "%s.Write([]byte(%s))\n", h, value)
+	default:
+		fmt.Fprintf(src, "%s(%s, %q, %s)\n", gen.qualified("fmt", "Fprintf"), h, "%#v", value)
+	}
+}
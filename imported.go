@@ -0,0 +1,163 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// importedConsumer finds a consumer type declared in another package, named
+// like shared.ExprConsumer. The qualifier is resolved against the imports of
+// the file declaring the node at.
+func (gen *generator) importedConsumer(at ast.Node, name string) (*ast.TypeSpec, error) {
+	qual, typeName, _ := strings.Cut(name, ".")
+
+	spec, ok := importNamed(fileContaining(gen.pkg, at), qual)
+	if !ok {
+		return nil, gen.errorAt(at, "package qualifier %s of consumer type %s does not match any import", qual, name)
+	}
+
+	dir, err := filepath.Abs(gen.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("can't find package %q: %w", spec.Path, err)
+	}
+	found, err := build.Import(spec.Path, dir, build.FindOnly)
+	if err != nil {
+		return nil, fmt.Errorf("can't find package %q: %w", spec.Path, err)
+	}
+
+	pkgs, err := parser.ParseDir(gen.fset, found.Dir, nil, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse package %q: %w", spec.Path, err)
+	}
+
+	pkg, err := importedPackage(pkgs, spec.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w in directory %q of %q", err, found.Dir, spec.Path)
+	}
+
+	gen.consumerPkg, gen.consumerImport = pkg, spec
+	return typeSpecNamed(pkg, typeName)
+}
+
+// importedPackage picks the package an import path refers to, out of the ones
+// parsed from its directory. External test packages don't count. When that
+// still leaves several, the one named after the last element of the path wins.
+func importedPackage(pkgs map[string]*ast.Package, importPath string) (*ast.Package, error) {
+	var names []string
+	for name := range pkgs {
+		if !strings.HasSuffix(name, "_test") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	switch {
+	case len(names) == 0:
+		return nil, errors.New("no package")
+	case len(names) == 1:
+		return pkgs[names[0]], nil
+	}
+
+	if pkg, ok := pkgs[defaultPackageName(importPath)]; ok {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("can't tell which of the packages %s is meant", strings.Join(names, ", "))
+}
+
+// resolveConsumerAlias finds the interface a consumer type stands for, when
+// it's declared as an alias (or a defined type) of one, eg.
+//
+//	type ExprConsumer = shared.ExprConsumer
+//
+// One level of that is resolved, whether the target is declared in the same
+// package or in an imported one. Other consumer types are returned as is.
+func (gen *generator) resolveConsumerAlias(spec *ast.TypeSpec) (*ast.TypeSpec, error) {
+	if spec.TypeParams != nil {
+		return spec, nil
+	}
+
+	var (
+		target *ast.TypeSpec
+		err    error
+	)
+	switch typ := spec.Type.(type) {
+	case *ast.Ident:
+		target, err = typeSpecNamed(gen.pkg, typ.Name)
+		if err != nil {
+			return spec, nil
+		}
+	case *ast.SelectorExpr:
+		if _, ok := typ.X.(*ast.Ident); !ok {
+			return spec, nil
+		}
+		target, err = gen.importedConsumer(spec, types.ExprString(typ))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return spec, nil
+	}
+
+	if target.TypeParams != nil {
+		return nil, gen.errorAt(spec, "consumer type %s can't alias the generic type %s", spec.Name.Name, types.ExprString(spec.Type))
+	}
+	gen.consumerAlias = spec
+	return target, nil
+}
+
+// consumerName is the name the consumer type is declared under in the source
+// package, or in the one it's imported from.
+func (gen *generator) consumerName() string {
+	if gen.consumerAlias != nil {
+		return gen.consumerAlias.Name.Name
+	}
+	return gen.consumer.Name.Name
+}
+
+// consumerPackage is the package declaring the consumer type.
+func (gen *generator) consumerPackage() *ast.Package {
+	if gen.consumerPkg != nil {
+		return gen.consumerPkg
+	}
+	return gen.pkg
+}
+
+// declaredByImportedConsumer tells whether the package of an imported
+// consumer declares a top-level name.
+func (gen *generator) declaredByImportedConsumer(name string) bool {
+	if gen.consumerPkg == nil {
+		return false
+	}
+	for _, f := range gen.consumerPkg.Files {
+		if f.Scope.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifyConsumerMethod makes a method of an imported consumer refer to the
+// names its package declares through the import, the way the generated code
+// has to.
+func (gen *generator) qualifyConsumerMethod(method *ast.Field) (*ast.Field, error) {
+	if gen.consumerPkg == nil {
+		return method, nil
+	}
+
+	typ, err := gen.qualifyType(gen.consumerImport.Path, gen.declaredByImportedConsumer, method.Type)
+	if err != nil {
+		return nil, err
+	}
+	return &ast.Field{Doc: method.Doc, Names: method.Names, Type: typ, Comment: method.Comment}, nil
+}
@@ -0,0 +1,332 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An import the generated file needs.
+type importSpec struct {
+	// Name is the explicit import name, or "" when the package is imported
+	// under its default name.
+	Name string
+	Path string
+}
+
+// collectImports records the imports needed by the type expressions in node,
+// which must come from the source file src.
+//
+// Qualified identifiers are resolved against the imports of src. Unqualified
+// identifiers that are neither predeclared nor declared in the package can
+// only come from dot imports -- since there's no telling which one, all the
+// dot imports of src are kept.
+func (gen *generator) collectImports(src *ast.File, node ast.Node) error {
+	if gen.imports == nil {
+		gen.imports = make(map[string]importSpec)
+	}
+
+	var err error
+	needDotImports := false
+
+	var visit func(node ast.Node) bool
+	visit = func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+
+		switch node := node.(type) {
+		case *ast.Field:
+			// Only the types matter, not the names of parameters, struct
+			// fields or interface methods.
+			ast.Inspect(node.Type, visit)
+			return false
+
+		case *ast.SelectorExpr:
+			qual, ok := node.X.(*ast.Ident)
+			if !ok {
+				return true
+			}
+
+			spec, found := importNamed(src, qual.Name)
+			switch {
+			case found:
+				err = gen.checkImportName(node, spec)
+				gen.imports[spec.Path] = spec
+			case qual.Name == gen.PackageName:
+				// A reference to the current package -- nothing to import.
+			default:
+				err = fmt.Errorf("package qualifier %s does not match any import in %s", qual.Name, gen.fset.Position(src.Pos()).Filename)
+			}
+			return false
+
+		case *ast.Ident:
+			if !gen.declared(node.Name) && !gen.declaredByImportedConsumer(node.Name) && !gen.isTypeParam(node.Name) && types.Universe.Lookup(node.Name) == nil {
+				needDotImports = true
+			}
+		}
+		return true
+	}
+	ast.Inspect(node, visit)
+	if err != nil {
+		return err
+	}
+
+	if needDotImports {
+		for _, imp := range src.Imports {
+			if imp.Name != nil && imp.Name.Name == "." {
+				spec := importSpecOf(imp)
+				gen.imports[spec.Path] = spec
+			}
+		}
+	}
+	return nil
+}
+
+// checkImportName makes sure the generated code can refer to an imported
+// package by the same name the source does. Since the composite and the
+// consumer might be declared in different files, the same package could be
+// imported under different names, or different packages under the same one.
+func (gen *generator) checkImportName(node ast.Node, spec importSpec) error {
+	for _, imp := range gen.imports {
+		switch {
+		case imp.Path == spec.Path && imp.localName() != spec.localName():
+			return gen.errorAt(node,
+				"package %q is imported as %s here, but as %s elsewhere (irgen needs a single name for it)",
+				spec.Path, spec.localName(), imp.localName())
+		case imp.Path != spec.Path && imp.localName() == spec.localName():
+			return gen.errorAt(node,
+				"%s refers to package %q here, but to %q elsewhere (irgen needs a single name for each)",
+				spec.localName(), spec.Path, imp.Path)
+		}
+	}
+	return nil
+}
+
+// qualified refers to a name exported by the package with the given import
+// path, making sure the generated code imports it. When the package is already
+// imported under some name (or with a dot), that's reused. Otherwise, when the
+// default name of the package means something else to the source, the import
+// gets an irgen prefixed name, eg. irgenjson.
+func (gen *generator) qualified(importPath, name string) string {
+	if gen.imports == nil {
+		gen.imports = make(map[string]importSpec)
+	}
+
+	spec, ok := gen.imports[importPath]
+	if !ok {
+		spec = importSpec{Path: importPath}
+		if local := defaultPackageName(importPath); gen.importNameTaken(local, importPath) {
+			spec.Name = "irgen" + local
+			for gen.importNameTaken(spec.Name, importPath) {
+				spec.Name += "_"
+			}
+		}
+		gen.imports[importPath] = spec
+	}
+
+	switch spec.Name {
+	case ".":
+		return name
+	case "":
+		return defaultPackageName(importPath) + "." + name
+	default:
+		return spec.Name + "." + name
+	}
+}
+
+// importNameTaken tells whether the generated code can't refer to the package
+// with the given import path by the name, because it already refers to
+// something else by it -- or the source does.
+func (gen *generator) importNameTaken(name, importPath string) bool {
+	if gen.declared(name) || gen.declaredByImportedConsumer(name) {
+		return true
+	}
+	for _, imp := range gen.imports {
+		if imp.Path != importPath && imp.localName() == name {
+			return true
+		}
+	}
+
+	pkgs := []*ast.Package{gen.pkg}
+	if gen.consumerPkg != nil {
+		pkgs = append(pkgs, gen.consumerPkg)
+	}
+	for _, pkg := range pkgs {
+		for _, f := range pkg.Files {
+			spec, ok := importNamed(f, name)
+			if ok && spec.Path != importPath {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// declared tells whether the source package declares a top-level name.
+func (gen *generator) declared(name string) bool {
+	for _, f := range gen.pkg.Files {
+		if f.Scope.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// importDecl builds the import declaration for the collected imports that
+// the declarations use, or returns nil when there's nothing to import.
+//
+// Like goimports does, the standard library packages come first and the rest
+// follow in a separate group, each group sorted by path.
+func (gen *generator) importDecl(decls []ast.Decl) (ast.Decl, error) {
+	used := usedQualifiers(decls)
+
+	var std, other []importSpec
+	for _, imp := range gen.imports {
+		if imp.Name != "." && !used[imp.localName()] {
+			continue
+		}
+
+		if isStandardLibrary(imp.Path) {
+			std = append(std, imp)
+		} else {
+			other = append(other, imp)
+		}
+	}
+
+	if len(std)+len(other) == 0 {
+		return nil, nil
+	}
+
+	// NOTE: There's no way to put a blank line between the groups without
+	// positions, so the declaration is parsed from source.
+	var lines []string
+	for _, group := range [][]importSpec{std, other} {
+		if len(group) == 0 {
+			continue
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+
+		sort.Slice(group, func(i, j int) bool { return group[i].Path < group[j].Path })
+		for _, imp := range group {
+			line := strconv.Quote(imp.Path)
+			if imp.Name != "" {
+				line = imp.Name + " " + line
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	var src strings.Builder
+	if len(lines) == 1 {
+		src.WriteString("import " + lines[0] + "\n")
+	} else {
+		src.WriteString("import (\n" + strings.Join(lines, "\n") + "\n)\n")
+	}
+
+	parsed, err := gen.parseDecls(src.String())
+	if err != nil {
+		return nil, err
+	}
+	return parsed[0], nil
+}
+
+// localName is the name the generated code refers to the package by.
+func (imp importSpec) localName() string {
+	if imp.Name != "" {
+		return imp.Name
+	}
+	return defaultPackageName(imp.Path)
+}
+
+// usedQualifiers collects the identifiers that appear as the left hand side of
+// a selector in the declarations -- which is how packages are referred to.
+func usedQualifiers(decls []ast.Decl) map[string]bool {
+	used := make(map[string]bool)
+	for _, decl := range decls {
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if sel, ok := node.(*ast.SelectorExpr); ok {
+				if qual, ok := sel.X.(*ast.Ident); ok {
+					used[qual.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return used
+}
+
+// isStandardLibrary tells whether the import path belongs to the standard
+// library, going by the convention that other paths start with a domain.
+func isStandardLibrary(importPath string) bool {
+	first := importPath
+	if i := strings.Index(importPath, "/"); i >= 0 {
+		first = importPath[:i]
+	}
+	return !strings.Contains(first, ".")
+}
+
+// importNamed finds the import through which the file refers to a package by
+// the given name.
+func importNamed(f *ast.File, name string) (importSpec, bool) {
+	for _, imp := range f.Imports {
+		spec := importSpecOf(imp)
+
+		localName := spec.Name
+		if localName == "" {
+			localName = defaultPackageName(spec.Path)
+		}
+
+		if localName == name {
+			return spec, true
+		}
+	}
+	return importSpec{}, false
+}
+
+func importSpecOf(imp *ast.ImportSpec) importSpec {
+	var spec importSpec
+	spec.Path, _ = strconv.Unquote(imp.Path.Value)
+	if imp.Name != nil {
+		spec.Name = imp.Name.Name
+	}
+	return spec
+}
+
+var (
+	majorVersionElem   = regexp.MustCompile(`^v[0-9]+$`)
+	gopkgVersionSuffix = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// defaultPackageName guesses the name of a package from its import path,
+// without loading it. This follows the usual conventions: the last path
+// element, skipping major version elements and gopkg.in style suffixes.
+func defaultPackageName(importPath string) string {
+	dir, name := path.Split(importPath)
+	if majorVersionElem.MatchString(name) && dir != "" {
+		name = path.Base(dir)
+	}
+	return gopkgVersionSuffix.ReplaceAllString(name, "")
+}
+
+// fileContaining returns the file of the package in which the node was
+// declared.
+func fileContaining(pkg *ast.Package, node ast.Node) *ast.File {
+	for _, f := range pkg.Files {
+		if f.Pos() <= node.Pos() && node.End() <= f.End() {
+			return f
+		}
+	}
+	return nil
+}
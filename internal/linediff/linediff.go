@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package linediff compares texts line by line, for showing how generated code
+// differs from what's expected.
+package linediff
+
+// An Edit is a step of turning one list of lines into another.
+type Edit struct {
+	// Op is ' ' for a line both lists have, '-' for one only the old list has
+	// and '+' for one only the new list has.
+	Op byte
+
+	Line string
+
+	// The indices the edit is at in the old and the new list.
+	Old, New int
+}
+
+// Lines lists the edits turning the old lines into the new ones, keeping the
+// longest common subsequence of them.
+//
+// NOTE: Finding that is quadratic -- but generated files are small enough for
+// it.
+func Lines(old, new []string) []Edit {
+	// common[i][j] is the length of the longest common subsequence of old[i:]
+	// and new[j:].
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			switch {
+			case old[i] == new[j]:
+				common[i][j] = common[i+1][j+1] + 1
+			case common[i+1][j] >= common[i][j+1]:
+				common[i][j] = common[i+1][j]
+			default:
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var edits []Edit
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			edits = append(edits, Edit{Op: ' ', Line: old[i], Old: i, New: j})
+			i, j = i+1, j+1
+		case i < len(old) && (j == len(new) || common[i+1][j] >= common[i][j+1]):
+			edits = append(edits, Edit{Op: '-', Line: old[i], Old: i, New: j})
+			i++
+		default:
+			edits = append(edits, Edit{Op: '+', Line: new[j], Old: i, New: j})
+			j++
+		}
+	}
+	return edits
+}
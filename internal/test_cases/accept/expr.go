@@ -0,0 +1,16 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package accept
+
+//go:generate irgen -v -method Accept -out ref.go Expr ExprVisitor
+
+type Expr interface {
+	Accept(v ExprVisitor)
+}
+
+type ExprVisitor interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
@@ -0,0 +1,17 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package accumulator
+
+//go:generate irgen -v -acc int -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer, acc *int)
+}
+
+type ExprConsumer interface {
+	Lit(N int, acc *int)
+	Var(Name string, acc *int)
+	Add(Left, Right Expr, acc *int)
+}
@@ -0,0 +1,21 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package accumulator
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer, acc *int) { consumer.Lit(e.N, acc) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer, acc *int) { consumer.Var(e.Name, acc) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer, acc *int) { consumer.Add(e.Left, e.Right, acc) }
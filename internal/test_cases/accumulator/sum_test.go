@@ -0,0 +1,33 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package accumulator
+
+import "testing"
+
+// Adds up the literals in an expression.
+type litSum struct{}
+
+func (litSum) Lit(N int, acc *int) { *acc += N }
+
+func (litSum) Var(Name string, acc *int) {}
+
+func (s litSum) Add(Left, Right Expr, acc *int) {
+	Left.FeedTo(s, acc)
+	Right.FeedTo(s, acc)
+}
+
+func TestSumLiterals(t *testing.T) {
+	e := &Add{
+		Left:  &Lit{N: 3},
+		Right: &Add{Left: &Var{Name: "x"}, Right: &Lit{N: 4}},
+	}
+
+	sum := 0
+	e.FeedTo(litSum{}, &sum)
+
+	if sum != 7 {
+		t.Errorf("got sum %d, want 7", sum)
+	}
+}
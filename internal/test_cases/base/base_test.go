@@ -0,0 +1,32 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package base
+
+import "testing"
+
+// Only the literals have a value, everything else counts as zero.
+type litValue struct {
+	BaseExprConsumer
+}
+
+func (litValue) Lit(N int) int { return N }
+
+func TestBaseConsumer(t *testing.T) {
+	exprs := []Expr{
+		&Lit{N: 3},
+		&Var{Name: "x"},
+		&Lit{N: 4},
+		&Call{Fn: "f", Args: []Expr{&Lit{N: 5}}},
+	}
+
+	sum := 0
+	for _, e := range exprs {
+		sum += e.FeedTo(litValue{})
+	}
+
+	if sum != 7 {
+		t.Errorf("got sum %d, want 7", sum)
+	}
+}
@@ -0,0 +1,17 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package base
+
+//go:generate irgen -v -base -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) int
+}
+
+type ExprConsumer interface {
+	Lit(N int) int
+	Var(Name string) int
+	Call(Fn string, Args ...Expr) int
+}
@@ -0,0 +1,29 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package base
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) int { return consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) int { return consumer.Var(e.Name) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) int { return consumer.Call(e.Fn, e.Args...) }
+
+type BaseExprConsumer struct {
+}
+
+func (BaseExprConsumer) Lit(N int) (_ int)                    { return }
+func (BaseExprConsumer) Var(Name string) (_ int)              { return }
+func (BaseExprConsumer) Call(Fn string, Args ...Expr) (_ int) { return }
@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package constructors
+
+import "testing"
+
+func TestConstructorsFillFields(t *testing.T) {
+	e := MakeAdd(MakeLit(1), MakeTyped(MakeVar("x"), "int"))
+
+	add, ok := e.(*Add)
+	if !ok {
+		t.Fatalf("got %T, want *Add", e)
+	}
+
+	if lit, ok := add.Left.(*Lit); !ok || lit.N != 1 {
+		t.Errorf("got left operand %#v, want &Lit{N: 1}", add.Left)
+	}
+
+	typed, ok := add.Right.(*Typed)
+	if !ok || typed.Type != "int" {
+		t.Fatalf("got right operand %#v, want a *Typed of type int", add.Right)
+	}
+	if v, ok := typed.Of.(*Var); !ok || v.Name != "x" {
+		t.Errorf("got typed expression %#v, want &Var{Name: \"x\"}", typed.Of)
+	}
+}
+
+func TestConcreteConstructorsFillFields(t *testing.T) {
+	typed := NewTyped(NewVar("x"), "int")
+
+	if typed.Type != "int" {
+		t.Errorf("got type %q, want %q", typed.Type, "int")
+	}
+	if v, ok := typed.Of.(*Var); !ok || v.Name != "x" {
+		t.Errorf("got typed expression %#v, want &Var{Name: \"x\"}", typed.Of)
+	}
+}
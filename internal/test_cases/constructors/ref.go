@@ -0,0 +1,73 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package constructors
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Typed struct {
+	Of   Expr
+	Type string
+}
+
+func (e *Typed) FeedTo(consumer ExprConsumer) { consumer.Typed(e.Of, e.Type) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Nil struct {
+}
+
+func (e *Nil) FeedTo(consumer ExprConsumer) { consumer.Nil() }
+
+func MakeLit(n int) Expr {
+	return &Lit{N: n}
+}
+
+func MakeVar(name string) Expr {
+	return &Var{Name: name}
+}
+
+func MakeTyped(of Expr, type_ string) Expr {
+	return &Typed{Of: of, Type: type_}
+}
+
+func MakeAdd(left, right Expr) Expr {
+	return &Add{Left: left, Right: right}
+}
+
+func MakeNil() Expr {
+	return &Nil{}
+}
+
+func NewLit(n int) *Lit {
+	return &Lit{N: n}
+}
+
+func NewVar(name string) *Var {
+	return &Var{Name: name}
+}
+
+func NewTyped(of Expr, type_ string) *Typed {
+	return &Typed{Of: of, Type: type_}
+}
+
+func NewAdd(left, right Expr) *Add {
+	return &Add{Left: left, Right: right}
+}
+
+func NewNil() *Nil {
+	return &Nil{}
+}
@@ -0,0 +1,38 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package copy
+
+import "testing"
+
+func TestMutatingACopyLeavesTheOriginal(t *testing.T) {
+	original := &Call{
+		Fn: "f",
+		Args: []Expr{
+			&Add{Left: &Lit{N: 1}, Right: &Tuple{Items: []int{2, 3}}},
+			&Lit{N: 4},
+		},
+	}
+	want := &Call{
+		Fn: "f",
+		Args: []Expr{
+			&Add{Left: &Lit{N: 1}, Right: &Tuple{Items: []int{2, 3}}},
+			&Lit{N: 4},
+		},
+	}
+
+	copied := original.Copy().(*Call)
+	copied.Fn = "g"
+	copied.Args[1] = &Lit{N: 5}
+	add := copied.Args[0].(*Add)
+	add.Left.(*Lit).N = 6
+	add.Right.(*Tuple).Items[0] = 7
+
+	if !original.Equal(want) {
+		t.Errorf("the original changed along with the copy")
+	}
+	if copied.Equal(want) {
+		t.Errorf("the copy didn't change")
+	}
+}
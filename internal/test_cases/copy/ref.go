@@ -0,0 +1,130 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package copy
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+type Tuple struct {
+	Items []int
+}
+
+func (e *Tuple) FeedTo(consumer ExprConsumer) { consumer.Tuple(e.Items) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Add) Equal(other Expr) bool {
+	that, ok := other.(*Add)
+	if !ok {
+		return false
+	}
+	if !equalExpr(e.Left, that.Left) {
+		return false
+	}
+	if !equalExpr(e.Right, that.Right) {
+		return false
+	}
+	return true
+}
+
+func (e *Call) Equal(other Expr) bool {
+	that, ok := other.(*Call)
+	if !ok {
+		return false
+	}
+	if e.Fn != that.Fn {
+		return false
+	}
+	if len(e.Args) != len(that.Args) {
+		return false
+	}
+	for i := range e.Args {
+		if !equalExpr(e.Args[i], that.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Tuple) Equal(other Expr) bool {
+	that, ok := other.(*Tuple)
+	if !ok {
+		return false
+	}
+	if len(e.Items) != len(that.Items) {
+		return false
+	}
+	for i := range e.Items {
+		if e.Items[i] != that.Items[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func copyExpr(e Expr) Expr {
+	if c, ok := e.(interface{ Copy() Expr }); ok {
+		return c.Copy()
+	}
+	return e
+}
+
+func (e *Lit) Copy() Expr {
+	return &Lit{N: e.N}
+}
+
+func (e *Add) Copy() Expr {
+	return &Add{Left: copyExpr(e.Left), Right: copyExpr(e.Right)}
+}
+
+func (e *Call) Copy() Expr {
+	return &Call{Fn: e.Fn, Args: copyExprs(e.Args)}
+}
+
+func (e *Tuple) Copy() Expr {
+	return &Tuple{Items: append([]int(nil), e.Items...)}
+}
+
+func copyExprs(es []Expr) []Expr {
+	if es == nil {
+		return nil
+	}
+	copies := make([]Expr, len(es))
+	for i, e := range es {
+		copies[i] = copyExpr(e)
+	}
+	return copies
+}
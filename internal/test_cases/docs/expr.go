@@ -0,0 +1,23 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package docs
+
+//go:generate irgen -v -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	// Lit is an integer literal.
+	Lit(N int)
+
+	Var(Name string) // Not a doc comment.
+
+	/*
+		Add is the sum of two expressions.
+	*/
+	Add(Left, Right Expr)
+}
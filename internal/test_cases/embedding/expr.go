@@ -0,0 +1,21 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package embedding
+
+//go:generate irgen -v -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type BaseConsumer interface {
+	Lit(N int)
+	Var(Name string)
+}
+
+type ExprConsumer interface {
+	BaseConsumer
+	Add(Left, Right Expr)
+}
@@ -0,0 +1,21 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package embedding
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
@@ -0,0 +1,47 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package equal
+
+import "testing"
+
+func TestEqual(t *testing.T) {
+	tree := func(n int) Expr {
+		return &Call{Fn: "f", Args: []Expr{
+			&Add{Left: &Lit{N: n}, Right: &Var{Name: "x"}},
+			&Tuple{Items: []int{1, 2}},
+		}}
+	}
+
+	tests := []struct {
+		name string
+		a, b Expr
+		want bool
+	}{
+		{"SameTrees", tree(1), tree(1), true},
+		{"NestedDifference", tree(1), tree(2), false},
+		{"DifferentVariants", &Lit{N: 1}, &Var{Name: "x"}, false},
+		{"DifferentArgCount", &Call{Fn: "f"}, &Call{Fn: "f", Args: []Expr{&Lit{}}}, false},
+		{"DifferentItems", &Tuple{Items: []int{1}}, &Tuple{Items: []int{2}}, false},
+		{"NilFields", &Add{}, &Add{}, true},
+		{"NilAgainstNonNil", &Add{}, &Add{Left: &Lit{}}, false},
+		{"NilFuncs", &Lambda{}, &Lambda{}, true},
+		{"NilAgainstNonNilFunc", &Lambda{}, &Lambda{Body: func(e Expr) Expr { return e }}, false},
+		{"SameGrids", &Grid{Cells: [][]int{{1, 2}, {3}}}, &Grid{Cells: [][]int{{1, 2}, {3}}}, true},
+		{"DifferentGrids", &Grid{Cells: [][]int{{1, 2}}}, &Grid{Cells: [][]int{{1, 3}}}, false},
+		{"SameEnvs", &Env{Vars: map[string][]int{"x": {1}}}, &Env{Vars: map[string][]int{"x": {1}}}, true},
+		{"DifferentEnvs", &Env{Vars: map[string][]int{"x": {1}}}, &Env{Vars: map[string][]int{"x": {2}}}, false},
+		{"Units", &Unit{}, &Unit{}, true},
+		{"UnitAgainstOther", &Unit{}, &Lit{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eq := tt.a.(interface{ Equal(Expr) bool })
+			if got := eq.Equal(tt.b); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package equal
+
+//go:generate irgen -v -equal -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Var(Name string)
+	Add(Left, Right Expr)
+	Call(Fn string, Args []Expr)
+	Tuple(Items []int)
+	Lambda(Body func(Expr) Expr)
+	Grid(Cells [][]int)
+	Env(Vars map[string][]int)
+	Unit()
+}
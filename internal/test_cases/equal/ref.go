@@ -0,0 +1,187 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package equal
+
+import "reflect"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+type Tuple struct {
+	Items []int
+}
+
+func (e *Tuple) FeedTo(consumer ExprConsumer) { consumer.Tuple(e.Items) }
+
+type Lambda struct {
+	Body func(Expr) Expr
+}
+
+func (e *Lambda) FeedTo(consumer ExprConsumer) { consumer.Lambda(e.Body) }
+
+type Grid struct {
+	Cells [][]int
+}
+
+func (e *Grid) FeedTo(consumer ExprConsumer) { consumer.Grid(e.Cells) }
+
+type Env struct {
+	Vars map[string][]int
+}
+
+func (e *Env) FeedTo(consumer ExprConsumer) { consumer.Env(e.Vars) }
+
+type Unit struct {
+}
+
+func (e *Unit) FeedTo(consumer ExprConsumer) { consumer.Unit() }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Var) Equal(other Expr) bool {
+	that, ok := other.(*Var)
+	if !ok {
+		return false
+	}
+	if e.Name != that.Name {
+		return false
+	}
+	return true
+}
+
+func (e *Add) Equal(other Expr) bool {
+	that, ok := other.(*Add)
+	if !ok {
+		return false
+	}
+	if !equalExpr(e.Left, that.Left) {
+		return false
+	}
+	if !equalExpr(e.Right, that.Right) {
+		return false
+	}
+	return true
+}
+
+func (e *Call) Equal(other Expr) bool {
+	that, ok := other.(*Call)
+	if !ok {
+		return false
+	}
+	if e.Fn != that.Fn {
+		return false
+	}
+	if len(e.Args) != len(that.Args) {
+		return false
+	}
+	for i := range e.Args {
+		if !equalExpr(e.Args[i], that.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Tuple) Equal(other Expr) bool {
+	that, ok := other.(*Tuple)
+	if !ok {
+		return false
+	}
+	if len(e.Items) != len(that.Items) {
+		return false
+	}
+	for i := range e.Items {
+		if e.Items[i] != that.Items[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Lambda) Equal(other Expr) bool {
+	that, ok := other.(*Lambda)
+	if !ok {
+		return false
+	}
+	if !reflect.DeepEqual(e.Body, that.Body) {
+		return false
+	}
+	return true
+}
+
+func (e *Grid) Equal(other Expr) bool {
+	that, ok := other.(*Grid)
+	if !ok {
+		return false
+	}
+	if len(e.Cells) != len(that.Cells) {
+		return false
+	}
+	for i := range e.Cells {
+		if !reflect.DeepEqual(e.Cells[i], that.Cells[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Env) Equal(other Expr) bool {
+	that, ok := other.(*Env)
+	if !ok {
+		return false
+	}
+	if len(e.Vars) != len(that.Vars) {
+		return false
+	}
+	for key, value := range e.Vars {
+		thatValue, ok := that.Vars[key]
+		if !ok || !reflect.DeepEqual(value, thatValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Unit) Equal(other Expr) bool {
+	_, ok := other.(*Unit)
+	return ok
+}
@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package fallible
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var errUnbound = errors.New("unbound variable")
+
+// Evaluates an expression, failing on variables missing from the environment.
+type evaluator struct {
+	env    map[string]int
+	result int
+}
+
+func (ev *evaluator) Lit(N int) error {
+	ev.result = N
+	return nil
+}
+
+func (ev *evaluator) Var(Name string) error {
+	n, ok := ev.env[Name]
+	if !ok {
+		return fmt.Errorf("%s: %w", Name, errUnbound)
+	}
+	ev.result = n
+	return nil
+}
+
+func (ev *evaluator) Add(Left, Right Expr) error {
+	err := Left.FeedTo(ev)
+	if err != nil {
+		return err
+	}
+	left := ev.result
+
+	err = Right.FeedTo(ev)
+	if err != nil {
+		return err
+	}
+	ev.result += left
+	return nil
+}
+
+func TestFeedToSucceeds(t *testing.T) {
+	e := &Add{Left: &Lit{N: 3}, Right: &Var{Name: "x"}}
+	ev := &evaluator{env: map[string]int{"x": 4}}
+
+	err := e.FeedTo(ev)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.result != 7 {
+		t.Errorf("got %d, want 7", ev.result)
+	}
+}
+
+func TestFeedToPropagatesErrors(t *testing.T) {
+	e := &Add{Left: &Lit{N: 3}, Right: &Var{Name: "y"}}
+	ev := &evaluator{env: map[string]int{"x": 4}}
+
+	err := e.FeedTo(ev)
+
+	if !errors.Is(err, errUnbound) {
+		t.Errorf("got error %v, want %v", err, errUnbound)
+	}
+}
@@ -0,0 +1,17 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package fallible
+
+//go:generate irgen -fallible -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) error
+}
+
+type ExprConsumer interface {
+	Lit(N int) error
+	Var(Name string) error
+	Add(Left, Right Expr) error
+}
@@ -0,0 +1,21 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package fallible
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) error { return consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) error { return consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) error { return consumer.Add(e.Left, e.Right) }
@@ -0,0 +1,13 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+//go:build ignore
+
+// This file is never built, and its syntax is that of some future Go.
+
+package files
+
+type ExprConsumer interface {
+	Lit(N int) => Expr
+}
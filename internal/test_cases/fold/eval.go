@@ -0,0 +1,17 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package fold
+
+// Eval computes the value of an expression.
+func Eval(e Expr) int {
+	return e.FeedTo(evaluator{})
+}
+
+type evaluator struct{}
+
+func (evaluator) Lit(n int) int            { return n }
+func (evaluator) Neg(of Expr) int          { return -Eval(of) }
+func (evaluator) Add(left, right Expr) int { return Eval(left) + Eval(right) }
+func (evaluator) Mul(left, right Expr) int { return Eval(left) * Eval(right) }
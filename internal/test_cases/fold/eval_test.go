@@ -0,0 +1,16 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package fold
+
+import "testing"
+
+func TestEval(t *testing.T) {
+	// -(2 + 3 * 4)
+	e := &Neg{Of: &Add{Left: &Lit{N: 2}, Right: &Mul{Left: &Lit{N: 3}, Right: &Lit{N: 4}}}}
+
+	if got := Eval(e); got != -14 {
+		t.Errorf("got %d, want %d", got, -14)
+	}
+}
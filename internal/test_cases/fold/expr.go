@@ -0,0 +1,18 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package fold
+
+//go:generate irgen -v -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) int
+}
+
+type ExprConsumer interface {
+	Lit(N int) int
+	Neg(Of Expr) int
+	Add(Left, Right Expr) int
+	Mul(Left, Right Expr) int
+}
@@ -0,0 +1,27 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package fold
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) int { return consumer.Lit(e.N) }
+
+type Neg struct {
+	Of Expr
+}
+
+func (e *Neg) FeedTo(consumer ExprConsumer) int { return consumer.Neg(e.Of) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) int { return consumer.Add(e.Left, e.Right) }
+
+type Mul struct {
+	Left, Right Expr
+}
+
+func (e *Mul) FeedTo(consumer ExprConsumer) int { return consumer.Mul(e.Left, e.Right) }
@@ -0,0 +1,33 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package foldfunc
+
+import "testing"
+
+func TestFoldEvaluates(t *testing.T) {
+	env := map[string]int{"x": 3}
+	eval := ExprHandlers[int]{
+		Lit: func(n int) int { return n },
+		Var: func(name string) int { return env[name] },
+		Add: func(left, right int) int { return left + right },
+		Sum: func(terms []int) int {
+			total := 0
+			for _, term := range terms {
+				total += term
+			}
+			return total
+		},
+	}
+
+	// 1 + (x + 2) + sum(4, 5)
+	e := &Add{
+		Left:  &Add{Left: &Lit{N: 1}, Right: &Add{Left: &Var{Name: "x"}, Right: &Lit{N: 2}}},
+		Right: &Sum{Terms: []Expr{&Lit{N: 4}, &Lit{N: 5}}},
+	}
+
+	if got := FoldExpr(e, eval); got != 15 {
+		t.Errorf("got %d, want %d", got, 15)
+	}
+}
@@ -0,0 +1,69 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package foldfunc
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Sum struct {
+	Terms []Expr
+}
+
+func (e *Sum) FeedTo(consumer ExprConsumer) { consumer.Sum(e.Terms) }
+
+type ExprHandlers[R any] struct {
+	Lit func(n int) R
+	Var func(name string) R
+	Add func(left, right R) R
+	Sum func(terms []R) R
+}
+
+func FoldExpr[R any](e Expr, h ExprHandlers[R]) R {
+	f := &exprFolder[R]{handlers: h}
+	e.FeedTo(f)
+	return f.result
+}
+
+type exprFolder[R any] struct {
+	handlers ExprHandlers[R]
+	result   R
+}
+
+func (f *exprFolder[R]) Lit(N int) {
+	f.result = f.handlers.Lit(N)
+}
+
+func (f *exprFolder[R]) Var(Name string) {
+	f.result = f.handlers.Var(Name)
+}
+
+func (f *exprFolder[R]) Add(Left, Right Expr) {
+	f.result = f.handlers.Add(FoldExpr(Left, f.handlers), FoldExpr(Right, f.handlers))
+}
+
+func (f *exprFolder[R]) Sum(Terms []Expr) {
+	f.result = f.handlers.Sum(foldExprs(Terms, f.handlers))
+}
+
+func foldExprs[R any](es []Expr, h ExprHandlers[R]) []R {
+	rs := make([]R, len(es))
+	for i, e := range es {
+		rs[i] = FoldExpr(e, h)
+	}
+	return rs
+}
@@ -0,0 +1,17 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package funcs
+
+//go:generate irgen -v -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lambda(Body func(Expr) Expr)
+	Hooks(OnEnter, OnExit func(name string) error)
+	Stream(Values <-chan Expr, Done chan struct{})
+}
@@ -0,0 +1,43 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package funcs
+
+import "testing"
+
+type recorder struct {
+	body    func(Expr) Expr
+	onEnter func(string) error
+	values  <-chan Expr
+}
+
+func (r *recorder) Lambda(body func(Expr) Expr) { r.body = body }
+
+func (r *recorder) Hooks(onEnter, onExit func(name string) error) { r.onEnter = onEnter }
+
+func (r *recorder) Stream(values <-chan Expr, done chan struct{}) { r.values = values }
+
+func TestFunctionAndChannelFieldsAreForwarded(t *testing.T) {
+	var r recorder
+
+	identity := &Lambda{Body: func(e Expr) Expr { return e }}
+	identity.FeedTo(&r)
+	if r.body == nil || r.body(identity) != identity {
+		t.Error("the lambda body was not forwarded")
+	}
+
+	entered := ""
+	hooks := &Hooks{OnEnter: func(name string) error { entered = name; return nil }}
+	hooks.FeedTo(&r)
+	if r.onEnter == nil || r.onEnter("x") != nil || entered != "x" {
+		t.Error("the enter hook was not forwarded")
+	}
+
+	values := make(chan Expr, 1)
+	values <- identity
+	(&Stream{Values: values}).FeedTo(&r)
+	if r.values == nil || <-r.values != identity {
+		t.Error("the values channel was not forwarded")
+	}
+}
@@ -0,0 +1,22 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package funcs
+
+type Lambda struct {
+	Body func(Expr) Expr
+}
+
+func (e *Lambda) FeedTo(consumer ExprConsumer) { consumer.Lambda(e.Body) }
+
+type Hooks struct {
+	OnEnter, OnExit func(name string) error
+}
+
+func (e *Hooks) FeedTo(consumer ExprConsumer) { consumer.Hooks(e.OnEnter, e.OnExit) }
+
+type Stream struct {
+	Values <-chan Expr
+	Done   chan struct{}
+}
+
+func (e *Stream) FeedTo(consumer ExprConsumer) { consumer.Stream(e.Values, e.Done) }
@@ -0,0 +1,35 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package generic
+
+type Leaf[T any] struct {
+	Value T
+}
+
+func (t *Leaf[T]) FeedTo(consumer TreeConsumer[T]) { consumer.Leaf(t.Value) }
+
+type Node[T any] struct {
+	Left, Right Tree[T]
+}
+
+func (t *Node[T]) FeedTo(consumer TreeConsumer[T]) { consumer.Node(t.Left, t.Right) }
+
+func MakeLeaf[T any](value T) Tree[T] {
+	return &Leaf[T]{Value: value}
+}
+
+func MakeNode[T any](left, right Tree[T]) Tree[T] {
+	return &Node[T]{Left: left, Right: right}
+}
+
+func MatchTree[T any](e Tree[T], onLeaf func(value T), onNode func(left, right Tree[T])) {
+	e.FeedTo(treeMatcher[T]{onLeaf: onLeaf, onNode: onNode})
+}
+
+type treeMatcher[T any] struct {
+	onLeaf func(value T)
+	onNode func(left, right Tree[T])
+}
+
+func (m treeMatcher[T]) Leaf(Value T)             { m.onLeaf(Value) }
+func (m treeMatcher[T]) Node(Left, Right Tree[T]) { m.onNode(Left, Right) }
@@ -0,0 +1,16 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package generic
+
+//go:generate irgen -v -constructors -match -out ref.go Tree TreeConsumer
+
+type Tree[T any] interface {
+	FeedTo(cons TreeConsumer[T])
+}
+
+type TreeConsumer[T any] interface {
+	Leaf(Value T)
+	Node(Left, Right Tree[T])
+}
@@ -0,0 +1,23 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package generic
+
+import "testing"
+
+func sum(t Tree[int]) int {
+	var total int
+	MatchTree(t,
+		func(value int) { total = value },
+		func(left, right Tree[int]) { total = sum(left) + sum(right) })
+	return total
+}
+
+func TestSum(t *testing.T) {
+	tree := MakeNode(MakeLeaf(1), MakeNode(MakeLeaf(2), MakeLeaf(3)))
+
+	if got := sum(tree); got != 6 {
+		t.Errorf("got %d, want %d", got, 6)
+	}
+}
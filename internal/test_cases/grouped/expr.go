@@ -0,0 +1,26 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package grouped
+
+//go:generate irgen -sealed -constructors -equal -verify -out ref.go Expr ExprConsumer
+
+type (
+	// Expr is an expression.
+	Expr interface {
+		isExpr()
+		FeedTo(cons ExprConsumer)
+	}
+
+	// ExprConsumer takes an expression apart.
+	ExprConsumer interface {
+		// Lit is a literal.
+		Lit(N int)
+		// Add is a sum.
+		Add(Left, Right Expr)
+	}
+
+	// Name is declared along with the types irgen needs.
+	Name string
+)
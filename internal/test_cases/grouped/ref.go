@@ -0,0 +1,61 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package grouped
+
+// Lit is a literal.
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+// Add is a sum.
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+func MakeLit(n int) Expr {
+	return &Lit{N: n}
+}
+
+func MakeAdd(left, right Expr) Expr {
+	return &Add{Left: left, Right: right}
+}
+
+func (*Lit) isExpr() {}
+func (*Add) isExpr() {}
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Add) Equal(other Expr) bool {
+	that, ok := other.(*Add)
+	if !ok {
+		return false
+	}
+	if !equalExpr(e.Left, that.Left) {
+		return false
+	}
+	if !equalExpr(e.Right, that.Right) {
+		return false
+	}
+	return true
+}
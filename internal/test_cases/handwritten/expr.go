@@ -0,0 +1,35 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package handwritten
+
+//go:generate irgen -methods-only -constructors -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+	Call(Fn string, Args ...Expr)
+}
+
+// The variants are declared by hand, with more than irgen would give them.
+
+type Lit struct {
+	N int `json:"n"`
+
+	// Where the literal comes from, which the consumer does not care about.
+	Line int
+}
+
+type Add struct {
+	Left, Right Expr
+}
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
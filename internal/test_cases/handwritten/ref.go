@@ -0,0 +1,19 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package handwritten
+
+func (e *Lit) FeedTo(consumer ExprConsumer)  { consumer.Lit(e.N) }
+func (e *Add) FeedTo(consumer ExprConsumer)  { consumer.Add(e.Left, e.Right) }
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args...) }
+
+func MakeLit(n int) Expr {
+	return &Lit{N: n}
+}
+
+func MakeAdd(left, right Expr) Expr {
+	return &Add{Left: left, Right: right}
+}
+
+func MakeCall(fn string, args []Expr) Expr {
+	return &Call{Fn: fn, Args: args}
+}
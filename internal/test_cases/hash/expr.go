@@ -0,0 +1,24 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hash
+
+//go:generate irgen -v -equal -hash -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int, Pos Pos)
+	Var(Name string)
+	Add(Left, Right Expr)
+	Call(Fn string, Args []Expr)
+	Let(Bindings map[string]Expr, Body *Expr)
+	Scale(Factor float64, Weights []uint)
+}
+
+type Pos struct {
+	Line, Column int
+}
@@ -0,0 +1,64 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package hash
+
+import "testing"
+
+type hasher interface {
+	Hash() uint64
+	Equal(other Expr) bool
+}
+
+func tree(n int, name string) Expr {
+	var body Expr = &Call{Fn: "f", Args: []Expr{&Var{Name: name}, nil}}
+	return &Let{
+		Bindings: map[string]Expr{
+			"a": &Add{Left: &Lit{N: n}, Right: &Var{Name: "x"}},
+			"b": &Scale{Factor: 1.5, Weights: []uint{1, 2}},
+			"c": &Lit{N: 3, Pos: Pos{Line: 1, Column: 2}},
+		},
+		Body: &body,
+	}
+}
+
+func TestEqualTreesHashEqually(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		a, b := tree(1, "y").(hasher), tree(1, "y").(hasher)
+		if !a.Equal(b.(Expr)) {
+			t.Fatal("trees built the same way are not equal")
+		}
+		if a.Hash() != b.Hash() {
+			t.Fatalf("equal trees hash to %x and %x", a.Hash(), b.Hash())
+		}
+	}
+}
+
+func TestDifferentTreesHashDifferently(t *testing.T) {
+	exprs := map[string]Expr{
+		"Tree":          tree(1, "y"),
+		"NestedLiteral": tree(2, "y"),
+		"NestedName":    tree(1, "z"),
+		"EmptyLet":      &Let{},
+		"Lit":           &Lit{N: 1},
+		"LitPos":        &Lit{N: 1, Pos: Pos{Line: 1}},
+		"Var":           &Var{Name: "x"},
+		"SplitNames":    &Call{Fn: "ab", Args: []Expr{&Var{Name: "c"}}},
+		"SplitNamesToo": &Call{Fn: "a", Args: []Expr{&Var{Name: "bc"}}},
+		"AddLeft":       &Add{Left: &Lit{N: 1}},
+		"AddRight":      &Add{Right: &Lit{N: 1}},
+		"Scale":         &Scale{Factor: 1, Weights: []uint{1}},
+		"ScaleWeights":  &Scale{Factor: 1, Weights: []uint{1, 1}},
+		"ScaleFactor":   &Scale{Factor: 2, Weights: []uint{1}},
+	}
+
+	seen := make(map[uint64]string)
+	for name, e := range exprs {
+		h := e.(hasher).Hash()
+		if other, ok := seen[h]; ok {
+			t.Errorf("%s and %s both hash to %x", name, other, h)
+		}
+		seen[h] = name
+	}
+}
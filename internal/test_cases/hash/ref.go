@@ -0,0 +1,240 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package hash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+)
+
+type Lit struct {
+	N   int
+	Pos Pos
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N, e.Pos) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+type Let struct {
+	Bindings map[string]Expr
+	Body     *Expr
+}
+
+func (e *Let) FeedTo(consumer ExprConsumer) { consumer.Let(e.Bindings, e.Body) }
+
+type Scale struct {
+	Factor  float64
+	Weights []uint
+}
+
+func (e *Scale) FeedTo(consumer ExprConsumer) { consumer.Scale(e.Factor, e.Weights) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	if e.Pos != that.Pos {
+		return false
+	}
+	return true
+}
+
+func (e *Var) Equal(other Expr) bool {
+	that, ok := other.(*Var)
+	if !ok {
+		return false
+	}
+	if e.Name != that.Name {
+		return false
+	}
+	return true
+}
+
+func (e *Add) Equal(other Expr) bool {
+	that, ok := other.(*Add)
+	if !ok {
+		return false
+	}
+	if !equalExpr(e.Left, that.Left) {
+		return false
+	}
+	if !equalExpr(e.Right, that.Right) {
+		return false
+	}
+	return true
+}
+
+func (e *Call) Equal(other Expr) bool {
+	that, ok := other.(*Call)
+	if !ok {
+		return false
+	}
+	if e.Fn != that.Fn {
+		return false
+	}
+	if len(e.Args) != len(that.Args) {
+		return false
+	}
+	for i := range e.Args {
+		if !equalExpr(e.Args[i], that.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Let) Equal(other Expr) bool {
+	that, ok := other.(*Let)
+	if !ok {
+		return false
+	}
+	if len(e.Bindings) != len(that.Bindings) {
+		return false
+	}
+	for key, value := range e.Bindings {
+		thatValue, ok := that.Bindings[key]
+		if !ok || !equalExpr(value, thatValue) {
+			return false
+		}
+	}
+	if !equalExprPtr(e.Body, that.Body) {
+		return false
+	}
+	return true
+}
+
+func (e *Scale) Equal(other Expr) bool {
+	that, ok := other.(*Scale)
+	if !ok {
+		return false
+	}
+	if e.Factor != that.Factor {
+		return false
+	}
+	if len(e.Weights) != len(that.Weights) {
+		return false
+	}
+	for i := range e.Weights {
+		if e.Weights[i] != that.Weights[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalExprPtr(a, b *Expr) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return equalExpr(*a, *b)
+}
+
+func hashExpr(e Expr) uint64 {
+	hasher, ok := e.(interface{ Hash() uint64 })
+	if !ok {
+		return 0
+	}
+	return hasher.Hash()
+}
+
+func (e *Lit) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("Lit"))
+	binary.Write(h, binary.LittleEndian, int64(e.N))
+	fmt.Fprintf(h, "%#v", e.Pos)
+	return h.Sum64()
+}
+
+func (e *Var) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("Var"))
+	binary.Write(h, binary.LittleEndian, uint64(len(e.Name)))
+	h.Write([]byte(e.Name))
+	return h.Sum64()
+}
+
+func (e *Add) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("Add"))
+	binary.Write(h, binary.LittleEndian, hashExpr(e.Left))
+	binary.Write(h, binary.LittleEndian, hashExpr(e.Right))
+	return h.Sum64()
+}
+
+func (e *Call) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("Call"))
+	binary.Write(h, binary.LittleEndian, uint64(len(e.Fn)))
+	h.Write([]byte(e.Fn))
+	binary.Write(h, binary.LittleEndian, uint64(len(e.Args)))
+	for _, item := range e.Args {
+		binary.Write(h, binary.LittleEndian, hashExpr(item))
+	}
+	return h.Sum64()
+}
+
+func (e *Let) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("Let"))
+	var sum uint64
+	for key, value := range e.Bindings {
+		entry := fnv.New64a()
+		binary.Write(entry, binary.LittleEndian, uint64(len(key)))
+		entry.Write([]byte(key))
+		binary.Write(entry, binary.LittleEndian, hashExpr(value))
+		sum += entry.Sum64()
+	}
+	binary.Write(h, binary.LittleEndian, uint64(len(e.Bindings)))
+	binary.Write(h, binary.LittleEndian, sum)
+	binary.Write(h, binary.LittleEndian, hashExprPtr(e.Body))
+	return h.Sum64()
+}
+
+func (e *Scale) Hash() uint64 {
+	h := fnv.New64a()
+	h.Write([]byte("Scale"))
+	binary.Write(h, binary.LittleEndian, e.Factor)
+	binary.Write(h, binary.LittleEndian, uint64(len(e.Weights)))
+	for _, item := range e.Weights {
+		binary.Write(h, binary.LittleEndian, uint64(item))
+	}
+	return h.Sum64()
+}
+
+func hashExprPtr(e *Expr) uint64 {
+	if e == nil {
+		return 0
+	}
+	return hashExpr(*e)
+}
@@ -0,0 +1,22 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package shared declares a consumer interface for a composite living in
+// another package.
+package shared
+
+import "time"
+
+type Pos int
+
+type ExprConsumer interface {
+	Lit(At Pos, N int)
+	Sleep(At Pos, For time.Duration)
+	Sum(At Pos, Terms []Pos)
+	Leaves
+}
+
+type Leaves interface {
+	Hole(At Pos)
+}
@@ -0,0 +1,13 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package pointer
+
+import "github.com/szabba/irgen/internal/test_cases/imported/internal/shared"
+
+//go:generate irgen -v -match -verify -out ref.go Expr shared.ExprConsumer
+
+type Expr interface {
+	FeedTo(cons *shared.ExprConsumer)
+}
@@ -0,0 +1,118 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package imported
+
+import (
+	"time"
+
+	"github.com/szabba/irgen/internal/test_cases/imported/internal/shared"
+)
+
+type Lit struct {
+	At shared.Pos
+	N  int
+}
+
+func (e *Lit) FeedTo(consumer shared.ExprConsumer) { consumer.Lit(e.At, e.N) }
+
+type Sleep struct {
+	At  shared.Pos
+	For time.Duration
+}
+
+func (e *Sleep) FeedTo(consumer shared.ExprConsumer) { consumer.Sleep(e.At, e.For) }
+
+type Sum struct {
+	At    shared.Pos
+	Terms []shared.Pos
+}
+
+func (e *Sum) FeedTo(consumer shared.ExprConsumer) { consumer.Sum(e.At, e.Terms) }
+
+type Hole struct {
+	At shared.Pos
+}
+
+func (e *Hole) FeedTo(consumer shared.ExprConsumer) { consumer.Hole(e.At) }
+
+func MatchExpr(e Expr, onLit func(at shared.Pos, n int), onSleep func(at shared.Pos, for_ time.Duration), onSum func(at shared.Pos, terms []shared.Pos), onHole func(at shared.Pos)) {
+	e.FeedTo(exprMatcher{onLit: onLit, onSleep: onSleep, onSum: onSum, onHole: onHole})
+}
+
+type exprMatcher struct {
+	onLit   func(at shared.Pos, n int)
+	onSleep func(at shared.Pos, for_ time.Duration)
+	onSum   func(at shared.Pos, terms []shared.Pos)
+	onHole  func(at shared.Pos)
+}
+
+func (m exprMatcher) Lit(At shared.Pos, N int)               { m.onLit(At, N) }
+func (m exprMatcher) Sleep(At shared.Pos, For time.Duration) { m.onSleep(At, For) }
+func (m exprMatcher) Sum(At shared.Pos, Terms []shared.Pos)  { m.onSum(At, Terms) }
+func (m exprMatcher) Hole(At shared.Pos)                     { m.onHole(At) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.At != that.At {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Sleep) Equal(other Expr) bool {
+	that, ok := other.(*Sleep)
+	if !ok {
+		return false
+	}
+	if e.At != that.At {
+		return false
+	}
+	if e.For != that.For {
+		return false
+	}
+	return true
+}
+
+func (e *Sum) Equal(other Expr) bool {
+	that, ok := other.(*Sum)
+	if !ok {
+		return false
+	}
+	if e.At != that.At {
+		return false
+	}
+	if len(e.Terms) != len(that.Terms) {
+		return false
+	}
+	for i := range e.Terms {
+		if e.Terms[i] != that.Terms[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Hole) Equal(other Expr) bool {
+	that, ok := other.(*Hole)
+	if !ok {
+		return false
+	}
+	if e.At != that.At {
+		return false
+	}
+	return true
+}
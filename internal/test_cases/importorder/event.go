@@ -0,0 +1,24 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package importorder
+
+import (
+	"time"
+
+	"github.com/szabba/irgen"
+
+	"context"
+)
+
+//go:generate irgen -v -out ref.go Event EventConsumer
+
+type Event interface {
+	FeedTo(cons EventConsumer)
+}
+
+type EventConsumer interface {
+	Failed(Config irgen.Config, At time.Time)
+	Cancelled(Ctx context.Context)
+}
@@ -0,0 +1,23 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package importorder
+
+import (
+	"context"
+	"time"
+
+	"github.com/szabba/irgen"
+)
+
+type Failed struct {
+	Config irgen.Config
+	At     time.Time
+}
+
+func (e *Failed) FeedTo(consumer EventConsumer) { consumer.Failed(e.Config, e.At) }
+
+type Cancelled struct {
+	Ctx context.Context
+}
+
+func (e *Cancelled) FeedTo(consumer EventConsumer) { consumer.Cancelled(e.Ctx) }
@@ -0,0 +1,21 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package imports
+
+import (
+	"context"
+	t "time"
+)
+
+//go:generate irgen -v -out ref.go Job JobHandler
+
+type Job interface {
+	Run(h JobHandler)
+}
+
+type JobHandler interface {
+	Cancel(Ctx context.Context)
+	Wait(Ctx context.Context, For t.Duration)
+}
@@ -5,21 +5,29 @@ package intexpr
 type Lit struct {
 	N int
 }
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
 type Var struct {
 	Name string
 }
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
 type Add struct {
 	Left, Right Expr
 }
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
 type Sub struct {
 	Left, Right Expr
 }
+
+func (e *Sub) FeedTo(consumer ExprConsumer) { consumer.Sub(e.Left, e.Right) }
+
 type Mul struct {
 	Left, Right Expr
 }
 
-func (Expr *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(Expr.N) }
-func (Expr *Var) FeedTo(consumer ExprConsumer) { consumer.Var(Expr.Name) }
-func (Expr *Add) FeedTo(consumer ExprConsumer) { consumer.Add(Expr.Left, Expr.Right) }
-func (Expr *Sub) FeedTo(consumer ExprConsumer) { consumer.Sub(Expr.Left, Expr.Right) }
-func (Expr *Mul) FeedTo(consumer ExprConsumer) { consumer.Mul(Expr.Left, Expr.Right) }
+func (e *Mul) FeedTo(consumer ExprConsumer) { consumer.Mul(e.Left, e.Right) }
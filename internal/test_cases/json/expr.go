@@ -0,0 +1,21 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package json
+
+//go:generate irgen -v -json -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int) //irgen:tag N json:"n"
+	Var(Name string)
+	Add(Left, Right Expr)
+	Call(Fn string, Args []Expr)
+	Nil()
+	Neg(X *Expr)
+	Seq(Items []*Expr)
+}
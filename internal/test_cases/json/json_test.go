@@ -0,0 +1,62 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package json
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var lit, name Expr = &Lit{N: 1}, &Var{Name: "x"}
+
+	for _, e := range []Expr{
+		&Add{Left: &Lit{N: 1}, Right: &Var{Name: "x"}},
+		&Call{Fn: "max", Args: []Expr{&Lit{N: 1}, &Nil{}}},
+		&Add{Left: &Lit{N: 1}},
+		&Call{Fn: "rand"},
+		&Neg{X: &lit},
+		&Neg{},
+		&Seq{Items: []*Expr{&lit, nil, &name}},
+		&Seq{},
+	} {
+		data, err := json.Marshal(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded ExprJSON
+		err = json.Unmarshal(data, &decoded)
+		if err != nil {
+			t.Fatalf("can't unmarshal %s: %s", data, err)
+		}
+
+		if !reflect.DeepEqual(decoded.Expr, e) {
+			t.Errorf("%s round-tripped to %#v", data, decoded.Expr)
+		}
+	}
+}
+
+func TestDiscriminator(t *testing.T) {
+	data, err := json.Marshal(&Add{Left: &Lit{N: 1}, Right: &Var{Name: "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"type":"Add","Left":{"type":"Lit","n":1},"Right":{"type":"Var","Name":"x"}}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestUnknownVariant(t *testing.T) {
+	var decoded ExprJSON
+	err := json.Unmarshal([]byte(`{"type":"Mul"}`), &decoded)
+	if err == nil || !strings.Contains(err.Error(), `unknown Expr variant "Mul"`) {
+		t.Errorf("got error %v, want one about an unknown variant", err)
+	}
+}
@@ -0,0 +1,216 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Lit struct {
+	N int `json:"n"`
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+type Nil struct {
+}
+
+func (e *Nil) FeedTo(consumer ExprConsumer) { consumer.Nil() }
+
+type Neg struct {
+	X *Expr
+}
+
+func (e *Neg) FeedTo(consumer ExprConsumer) { consumer.Neg(e.X) }
+
+type Seq struct {
+	Items []*Expr
+}
+
+func (e *Seq) FeedTo(consumer ExprConsumer) { consumer.Seq(e.Items) }
+
+func (e *Lit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		N    int    `json:"n"`
+	}{"Lit", e.N})
+}
+
+func (e *Var) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Name string
+	}{"Var", e.Name})
+}
+
+func (e *Add) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		Left  Expr
+		Right Expr
+	}{"Add", e.Left, e.Right})
+}
+
+func (e *Call) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Fn   string
+		Args []Expr
+	}{"Call", e.Fn, e.Args})
+}
+
+func (e *Nil) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+	}{"Nil"})
+}
+
+func (e *Neg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		X    *Expr
+	}{"Neg", e.X})
+}
+
+func (e *Seq) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		Items []*Expr
+	}{"Seq", e.Items})
+}
+
+type ExprJSON struct {
+	Expr Expr
+}
+
+func (w ExprJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.Expr)
+}
+
+func (w *ExprJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		w.Expr = nil
+		return nil
+	}
+
+	var tagged struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(data, &tagged)
+	if err != nil {
+		return err
+	}
+
+	switch tagged.Type {
+	case "Lit":
+		var v struct {
+			N int `json:"n"`
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Lit{N: v.N}
+	case "Var":
+		var v struct {
+			Name string
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Var{Name: v.Name}
+	case "Add":
+		var v struct {
+			Left  ExprJSON
+			Right ExprJSON
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Add{Left: v.Left.Expr, Right: v.Right.Expr}
+	case "Call":
+		var v struct {
+			Fn   string
+			Args []ExprJSON
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Call{Fn: v.Fn, Args: unwrapExprs(v.Args)}
+	case "Nil":
+		w.Expr = &Nil{}
+	case "Neg":
+		var v struct {
+			X *ExprJSON
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Neg{X: unwrapExprPtr(v.X)}
+	case "Seq":
+		var v struct {
+			Items []*ExprJSON
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Seq{Items: unwrapExprPtrs(v.Items)}
+	default:
+		return fmt.Errorf("unknown Expr variant %q", tagged.Type)
+	}
+	return nil
+}
+
+func unwrapExprs(ws []ExprJSON) []Expr {
+	if ws == nil {
+		return nil
+	}
+	es := make([]Expr, len(ws))
+	for i, w := range ws {
+		es[i] = w.Expr
+	}
+	return es
+}
+
+func unwrapExprPtr(w *ExprJSON) *Expr {
+	if w == nil {
+		return nil
+	}
+	return &w.Expr
+}
+
+func unwrapExprPtrs(ws []*ExprJSON) []*Expr {
+	if ws == nil {
+		return nil
+	}
+	es := make([]*Expr, len(ws))
+	for i, w := range ws {
+		es[i] = unwrapExprPtr(w)
+	}
+	return es
+}
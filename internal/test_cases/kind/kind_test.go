@@ -0,0 +1,46 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package kind
+
+import "testing"
+
+func TestVariantsHaveDistinctKinds(t *testing.T) {
+	for _, tc := range []struct {
+		e    Expr
+		want ExprKind
+		name string
+	}{
+		{&Lit{}, LitKind, "Lit"},
+		{&Var{}, VarKind, "Var"},
+		{&Add{}, AddKind, "Add"},
+	} {
+		if got := tc.e.Kind(); got != tc.want {
+			t.Errorf("%T has kind %v, want %v", tc.e, got, tc.want)
+		}
+		if got := tc.e.Kind().String(); got != tc.name {
+			t.Errorf("%T has kind named %q, want %q", tc.e, got, tc.name)
+		}
+	}
+
+	if LitKind == VarKind || VarKind == AddKind || LitKind == AddKind {
+		t.Errorf("kinds are not distinct: %d, %d, %d", LitKind, VarKind, AddKind)
+	}
+}
+
+func TestSwitchOnKind(t *testing.T) {
+	var e Expr = &Add{Left: &Lit{N: 1}, Right: &Var{Name: "x"}}
+
+	switch e.Kind() {
+	case AddKind:
+	default:
+		t.Errorf("got kind %v, want %v", e.Kind(), AddKind)
+	}
+}
+
+func TestUnknownKindString(t *testing.T) {
+	if got, want := ExprKind(42).String(), "ExprKind(42)"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
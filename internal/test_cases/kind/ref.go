@@ -0,0 +1,47 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package kind
+
+import "fmt"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type ExprKind int
+
+const (
+	LitKind ExprKind = iota
+	VarKind
+	AddKind
+)
+
+func (e *Lit) Kind() ExprKind { return LitKind }
+func (e *Var) Kind() ExprKind { return VarKind }
+func (e *Add) Kind() ExprKind { return AddKind }
+
+func (k ExprKind) String() string {
+	switch k {
+	case LitKind:
+		return "Lit"
+	case VarKind:
+		return "Var"
+	case AddKind:
+		return "Add"
+	}
+	return fmt.Sprintf("ExprKind(%d)", int(k))
+}
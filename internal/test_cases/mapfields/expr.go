@@ -0,0 +1,21 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mapfields
+
+//go:generate irgen -v -equal -copy -string -walk -map -json -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Record(Fields map[string]Expr)
+	Env(Vars map[string]int)
+	Pair(Both struct {
+		K string
+		V Expr
+	})
+}
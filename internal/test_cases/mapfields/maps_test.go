@@ -0,0 +1,98 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package mapfields
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func example() Expr {
+	return &Record{Fields: map[string]Expr{
+		"a": &Lit{N: 1},
+		"b": &Record{Fields: map[string]Expr{"c": &Lit{N: 2}}},
+		"d": &Env{Vars: map[string]int{"x": 3}},
+	}}
+}
+
+func TestWalkVisitsMapValues(t *testing.T) {
+	sum := 0
+	WalkExpr(example(), func(e Expr) bool {
+		if lit, ok := e.(*Lit); ok {
+			sum += lit.N
+		}
+		return true
+	})
+
+	if sum != 3 {
+		t.Errorf("got literals summing up to %d, want 3", sum)
+	}
+}
+
+func TestEqualComparesMaps(t *testing.T) {
+	if !example().(*Record).Equal(example()) {
+		t.Error("equal records are reported as different")
+	}
+
+	other := example().(*Record)
+	other.Fields["b"].(*Record).Fields["c"] = &Lit{N: 5}
+	if example().(*Record).Equal(other) {
+		t.Error("records with different nested values are reported as equal")
+	}
+
+	missing := example().(*Record)
+	delete(missing.Fields, "a")
+	missing.Fields["e"] = &Lit{N: 1}
+	if example().(*Record).Equal(missing) {
+		t.Error("records with different keys are reported as equal")
+	}
+}
+
+func TestCopyIsDeep(t *testing.T) {
+	e := example().(*Record)
+	copied := e.Copy().(*Record)
+
+	copied.Fields["b"].(*Record).Fields["c"] = &Lit{N: 5}
+	copied.Fields["d"].(*Env).Vars["x"] = 4
+
+	if !e.Equal(example()) {
+		t.Errorf("modifying the copy changed the original to %s", e)
+	}
+}
+
+func TestMapRebuildsMapValues(t *testing.T) {
+	doubled := MapExpr(example(), func(e Expr) Expr {
+		if lit, ok := e.(*Lit); ok {
+			return &Lit{N: 2 * lit.N}
+		}
+		return e
+	})
+
+	want := &Record{Fields: map[string]Expr{
+		"a": &Lit{N: 2},
+		"b": &Record{Fields: map[string]Expr{"c": &Lit{N: 4}}},
+		"d": &Env{Vars: map[string]int{"x": 3}},
+	}}
+	if !want.Equal(doubled) {
+		t.Errorf("got %s, want %s", doubled, want)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	data, err := json.Marshal(example())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ExprJSON
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !example().(*Record).Equal(decoded.Expr) {
+		t.Errorf("got %s from %s", decoded.Expr, data)
+	}
+}
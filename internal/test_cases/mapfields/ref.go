@@ -0,0 +1,298 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package mapfields
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Record struct {
+	Fields map[string]Expr
+}
+
+func (e *Record) FeedTo(consumer ExprConsumer) { consumer.Record(e.Fields) }
+
+type Env struct {
+	Vars map[string]int
+}
+
+func (e *Env) FeedTo(consumer ExprConsumer) { consumer.Env(e.Vars) }
+
+type Pair struct {
+	Both struct {
+		K string
+		V Expr
+	}
+}
+
+func (e *Pair) FeedTo(consumer ExprConsumer) { consumer.Pair(e.Both) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Record) Equal(other Expr) bool {
+	that, ok := other.(*Record)
+	if !ok {
+		return false
+	}
+	if len(e.Fields) != len(that.Fields) {
+		return false
+	}
+	for key, value := range e.Fields {
+		thatValue, ok := that.Fields[key]
+		if !ok || !equalExpr(value, thatValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Env) Equal(other Expr) bool {
+	that, ok := other.(*Env)
+	if !ok {
+		return false
+	}
+	if len(e.Vars) != len(that.Vars) {
+		return false
+	}
+	for key, value := range e.Vars {
+		thatValue, ok := that.Vars[key]
+		if !ok || value != thatValue {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Pair) Equal(other Expr) bool {
+	that, ok := other.(*Pair)
+	if !ok {
+		return false
+	}
+	if e.Both != that.Both {
+		return false
+	}
+	return true
+}
+
+func copyExpr(e Expr) Expr {
+	if c, ok := e.(interface{ Copy() Expr }); ok {
+		return c.Copy()
+	}
+	return e
+}
+
+func (e *Lit) Copy() Expr {
+	return &Lit{N: e.N}
+}
+
+func (e *Record) Copy() Expr {
+	return &Record{Fields: copyExprMap(e.Fields)}
+}
+
+func (e *Env) Copy() Expr {
+	return &Env{Vars: maps.Clone(e.Vars)}
+}
+
+func (e *Pair) Copy() Expr {
+	return &Pair{Both: e.Both}
+}
+
+func copyExprMap[K comparable](es map[K]Expr) map[K]Expr {
+	if es == nil {
+		return nil
+	}
+	copies := make(map[K]Expr, len(es))
+	for key, e := range es {
+		copies[key] = copyExpr(e)
+	}
+	return copies
+}
+
+func stringExpr(e Expr) string {
+	if s, ok := e.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+func (e *Lit) String() string {
+	return fmt.Sprintf("Lit{N: %v}", e.N)
+}
+
+func (e *Record) String() string {
+	return fmt.Sprintf("Record{Fields: %v}", e.Fields)
+}
+
+func (e *Env) String() string {
+	return fmt.Sprintf("Env{Vars: %v}", e.Vars)
+}
+
+func (e *Pair) String() string {
+	return fmt.Sprintf("Pair{Both: %v}", e.Both)
+}
+
+func (e *Lit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		N    int
+	}{"Lit", e.N})
+}
+
+func (e *Record) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type   string `json:"type"`
+		Fields map[string]Expr
+	}{"Record", e.Fields})
+}
+
+func (e *Env) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Vars map[string]int
+	}{"Env", e.Vars})
+}
+
+func (e *Pair) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Both struct {
+			K string
+			V Expr
+		}
+	}{"Pair", e.Both})
+}
+
+type ExprJSON struct {
+	Expr Expr
+}
+
+func (w ExprJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.Expr)
+}
+
+func (w *ExprJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		w.Expr = nil
+		return nil
+	}
+
+	var tagged struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(data, &tagged)
+	if err != nil {
+		return err
+	}
+
+	switch tagged.Type {
+	case "Lit":
+		var v struct {
+			N int
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Lit{N: v.N}
+	case "Record":
+		var v struct {
+			Fields map[string]ExprJSON
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Record{Fields: unwrapExprMap(v.Fields)}
+	case "Env":
+		var v struct {
+			Vars map[string]int
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Env{Vars: v.Vars}
+	case "Pair":
+		var v struct {
+			Both struct {
+				K string
+				V Expr
+			}
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = &Pair{Both: v.Both}
+	default:
+		return fmt.Errorf("unknown Expr variant %q", tagged.Type)
+	}
+	return nil
+}
+
+func unwrapExprMap[K comparable](ws map[K]ExprJSON) map[K]Expr {
+	if ws == nil {
+		return nil
+	}
+	es := make(map[K]Expr, len(ws))
+	for key, w := range ws {
+		es[key] = w.Expr
+	}
+	return es
+}
+
+func WalkExpr(e Expr, pre func(Expr) bool) {
+	if e == nil || !pre(e) {
+		return
+	}
+	switch v := e.(type) {
+	case *Record:
+		for _, child := range v.Fields {
+			WalkExpr(child, pre)
+		}
+	}
+}
+
+func MapExpr(e Expr, f func(Expr) Expr) Expr {
+	switch v := e.(type) {
+	case *Record:
+		e = &Record{Fields: mapExprMap(v.Fields, f)}
+	}
+	return f(e)
+}
+
+func mapExprMap[K comparable](es map[K]Expr, f func(Expr) Expr) map[K]Expr {
+	if es == nil {
+		return nil
+	}
+	mapped := make(map[K]Expr, len(es))
+	for key, e := range es {
+		mapped[key] = MapExpr(e, f)
+	}
+	return mapped
+}
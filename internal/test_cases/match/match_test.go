@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package match
+
+import "testing"
+
+func eval(e Expr, env map[string]int) int {
+	var value int
+	MatchExpr(e,
+		func(n int) { value = n },
+		func(name string) { value = env[name] },
+		func(left, right Expr) { value = eval(left, env) + eval(right, env) })
+	return value
+}
+
+func TestMatchEvaluates(t *testing.T) {
+	// 1 + (x + 2)
+	e := &Add{Left: &Lit{N: 1}, Right: &Add{Left: &Var{Name: "x"}, Right: &Lit{N: 2}}}
+
+	if got := eval(e, map[string]int{"x": 3}); got != 6 {
+		t.Errorf("got %d, want %d", got, 6)
+	}
+}
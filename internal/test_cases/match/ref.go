@@ -0,0 +1,35 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package match
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+func MatchExpr(e Expr, onLit func(n int), onVar func(name string), onAdd func(left, right Expr)) {
+	e.FeedTo(exprMatcher{onLit: onLit, onVar: onVar, onAdd: onAdd})
+}
+
+type exprMatcher struct {
+	onLit func(n int)
+	onVar func(name string)
+	onAdd func(left, right Expr)
+}
+
+func (m exprMatcher) Lit(N int)            { m.onLit(N) }
+func (m exprMatcher) Var(Name string)      { m.onVar(Name) }
+func (m exprMatcher) Add(Left, Right Expr) { m.onAdd(Left, Right) }
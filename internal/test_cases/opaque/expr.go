@@ -0,0 +1,26 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package opaque
+
+import "go/token"
+
+//go:generate irgen -equal -walk -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Loc(Pos token.Position, Of Expr)
+	Annotated(Note Note)
+}
+
+// Note is extra data about an expression, which irgen does not look into, even
+// though it refers to one.
+type Note struct {
+	Text  string
+	About Expr
+}
@@ -0,0 +1,78 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package opaque
+
+import "go/token"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Loc struct {
+	Pos token.Position
+	Of  Expr
+}
+
+func (e *Loc) FeedTo(consumer ExprConsumer) { consumer.Loc(e.Pos, e.Of) }
+
+type Annotated struct {
+	Note Note
+}
+
+func (e *Annotated) FeedTo(consumer ExprConsumer) { consumer.Annotated(e.Note) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Loc) Equal(other Expr) bool {
+	that, ok := other.(*Loc)
+	if !ok {
+		return false
+	}
+	if e.Pos != that.Pos {
+		return false
+	}
+	if !equalExpr(e.Of, that.Of) {
+		return false
+	}
+	return true
+}
+
+func (e *Annotated) Equal(other Expr) bool {
+	that, ok := other.(*Annotated)
+	if !ok {
+		return false
+	}
+	if e.Note != that.Note {
+		return false
+	}
+	return true
+}
+
+func WalkExpr(e Expr, pre func(Expr) bool) {
+	if e == nil || !pre(e) {
+		return
+	}
+	switch v := e.(type) {
+	case *Loc:
+		WalkExpr(v.Of, pre)
+	}
+}
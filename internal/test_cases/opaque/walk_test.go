@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package opaque
+
+import (
+	"fmt"
+	"go/token"
+	"testing"
+)
+
+func visited(e Expr) []string {
+	var nodes []string
+	WalkExpr(e, func(e Expr) bool {
+		nodes = append(nodes, fmt.Sprintf("%T", e))
+		return true
+	})
+	return nodes
+}
+
+func TestWalkSkipsPosition(t *testing.T) {
+	e := &Loc{Pos: token.Position{Filename: "a.go", Line: 1}, Of: &Lit{N: 1}}
+
+	got, want := visited(e), []string{"*opaque.Loc", "*opaque.Lit"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got nodes %v, want %v", got, want)
+	}
+}
+
+func TestWalkSkipsNamedStruct(t *testing.T) {
+	e := &Annotated{Note: Note{Text: "unused", About: &Lit{N: 1}}}
+
+	got, want := visited(e), []string{"*opaque.Annotated"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got nodes %v, want %v", got, want)
+	}
+}
+
+func TestEqualComparesPositionsWhole(t *testing.T) {
+	pos := token.Position{Filename: "a.go", Line: 1}
+	a := &Loc{Pos: pos, Of: &Lit{N: 1}}
+
+	if !a.Equal(&Loc{Pos: pos, Of: &Lit{N: 1}}) {
+		t.Error("locations with equal positions are not equal")
+	}
+
+	pos.Column = 2
+	if a.Equal(&Loc{Pos: pos, Of: &Lit{N: 1}}) {
+		t.Error("locations with different positions are equal")
+	}
+}
@@ -0,0 +1,33 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package expr_test
+
+import (
+	"testing"
+
+	expr "github.com/szabba/irgen/internal/test_cases/outpkg"
+)
+
+func eval(e expr.Expr) int {
+	var value int
+	MatchExpr(e,
+		func(n int) { value = n },
+		func(of expr.Expr) { value = -eval(of) },
+		func(terms []expr.Expr) {
+			for _, term := range terms {
+				value += eval(term)
+			}
+		})
+	return value
+}
+
+func TestEvalInAnotherPackage(t *testing.T) {
+	// 1 + -(2) + 3
+	e := MakeSum([]expr.Expr{MakeLit(1), MakeNeg(MakeLit(2)), MakeLit(3)})
+
+	if got := eval(e); got != 2 {
+		t.Errorf("got %d, want %d", got, 2)
+	}
+}
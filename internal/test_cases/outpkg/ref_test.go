@@ -0,0 +1,49 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package expr_test
+
+import expr "github.com/szabba/irgen/internal/test_cases/outpkg"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer expr.ExprConsumer) { consumer.Lit(e.N) }
+
+type Neg struct {
+	Of expr.Expr
+}
+
+func (e *Neg) FeedTo(consumer expr.ExprConsumer) { consumer.Neg(e.Of) }
+
+type Sum struct {
+	Terms []expr.Expr
+}
+
+func (e *Sum) FeedTo(consumer expr.ExprConsumer) { consumer.Sum(e.Terms) }
+
+func MakeLit(n int) expr.Expr {
+	return &Lit{N: n}
+}
+
+func MakeNeg(of expr.Expr) expr.Expr {
+	return &Neg{Of: of}
+}
+
+func MakeSum(terms []expr.Expr) expr.Expr {
+	return &Sum{Terms: terms}
+}
+
+func MatchExpr(e expr.Expr, onLit func(n int), onNeg func(of expr.Expr), onSum func(terms []expr.Expr)) {
+	e.FeedTo(exprMatcher{onLit: onLit, onNeg: onNeg, onSum: onSum})
+}
+
+type exprMatcher struct {
+	onLit func(n int)
+	onNeg func(of expr.Expr)
+	onSum func(terms []expr.Expr)
+}
+
+func (m exprMatcher) Lit(N int)             { m.onLit(N) }
+func (m exprMatcher) Neg(Of expr.Expr)      { m.onNeg(Of) }
+func (m exprMatcher) Sum(Terms []expr.Expr) { m.onSum(Terms) }
@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package passthrough
+
+import (
+	"context"
+	"testing"
+)
+
+type envKey struct{}
+
+// Evaluates an expression, looking variables up in the context.
+type evaluator struct{}
+
+func (evaluator) Lit(Ctx context.Context, N int) int { return N }
+
+func (evaluator) Var(Ctx context.Context, Name string) int {
+	return Ctx.Value(envKey{}).(map[string]int)[Name]
+}
+
+func (ev evaluator) Add(Ctx context.Context, Left, Right Expr) int {
+	return Left.FeedTo(Ctx, ev) + Right.FeedTo(Ctx, ev)
+}
+
+func TestContextIsPassedThrough(t *testing.T) {
+	e := &Add{Left: &Lit{N: 3}, Right: &Var{Name: "x"}}
+	ctx := context.WithValue(context.Background(), envKey{}, map[string]int{"x": 4})
+
+	got := e.FeedTo(ctx, evaluator{})
+
+	if got != 7 {
+		t.Errorf("got %d, want 7", got)
+	}
+}
@@ -0,0 +1,22 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package passthrough
+
+import "context"
+
+//go:generate irgen -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(ctx context.Context, cons ExprConsumer) int
+}
+
+// The context is passed to each method, but not stored in the variants.
+//
+//irgen:passthrough Ctx
+type ExprConsumer interface {
+	Lit(Ctx context.Context, N int) int
+	Var(Ctx context.Context, Name string) int
+	Add(Ctx context.Context, Left, Right Expr) int
+}
@@ -0,0 +1,27 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package passthrough
+
+import "context"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(ctx context.Context, consumer ExprConsumer) int { return consumer.Lit(ctx, e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(ctx context.Context, consumer ExprConsumer) int {
+	return consumer.Var(ctx, e.Name)
+}
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(ctx context.Context, consumer ExprConsumer) int {
+	return consumer.Add(ctx, e.Left, e.Right)
+}
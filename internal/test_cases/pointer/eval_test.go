@@ -0,0 +1,59 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package pointer
+
+import "testing"
+
+func eval(e Expr) int {
+	return FoldExpr(e, ExprHandlers[int]{
+		Lit: func(n int) int { return n },
+		Add: func(left, right int) int { return left + right },
+	})
+}
+
+func TestFoldThroughPointer(t *testing.T) {
+	e := &Add{Left: &Lit{N: 3}, Right: &Add{Left: &Lit{N: 4}, Right: &Lit{N: 5}}}
+
+	if got := eval(e); got != 12 {
+		t.Errorf("got %d, want 12", got)
+	}
+}
+
+func TestMatchThroughPointer(t *testing.T) {
+	var got int
+	MatchExpr(&Lit{N: 3}, func(n int) { got = n }, func(left, right Expr) {})
+
+	if got != 3 {
+		t.Errorf("got %d, want 3", got)
+	}
+}
+
+// Sums the literals of an expression, keeping the running total in itself.
+type summer struct {
+	sum int
+}
+
+func (s *summer) Lit(N int) { s.sum += N }
+
+func (s *summer) Add(Left, Right Expr) {
+	var consumer ExprConsumer = s
+	Left.FeedTo(&consumer)
+	Right.FeedTo(&consumer)
+}
+
+func TestConsumerAccumulatesThroughPointer(t *testing.T) {
+	e := &Add{Left: &Lit{N: 3}, Right: &Add{Left: &Lit{N: 4}, Right: &Lit{N: 5}}}
+
+	s := &summer{}
+	var consumer ExprConsumer = s
+	e.FeedTo(&consumer)
+
+	if s.sum != 12 {
+		t.Errorf("got sum %d, want 12", s.sum)
+	}
+	if consumer != ExprConsumer(s) {
+		t.Errorf("the consumer was replaced with %#v", consumer)
+	}
+}
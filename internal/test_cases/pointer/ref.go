@@ -0,0 +1,53 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package pointer
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer *ExprConsumer) { (*consumer).Lit(e.N) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer *ExprConsumer) { (*consumer).Add(e.Left, e.Right) }
+
+func MatchExpr(e Expr, onLit func(n int), onAdd func(left, right Expr)) {
+	var consumer ExprConsumer = exprMatcher{onLit: onLit, onAdd: onAdd}
+	e.FeedTo(&consumer)
+}
+
+type exprMatcher struct {
+	onLit func(n int)
+	onAdd func(left, right Expr)
+}
+
+func (m exprMatcher) Lit(N int)            { m.onLit(N) }
+func (m exprMatcher) Add(Left, Right Expr) { m.onAdd(Left, Right) }
+
+type ExprHandlers[R any] struct {
+	Lit func(n int) R
+	Add func(left, right R) R
+}
+
+func FoldExpr[R any](e Expr, h ExprHandlers[R]) R {
+	f := &exprFolder[R]{handlers: h}
+	var consumer ExprConsumer = f
+	e.FeedTo(&consumer)
+	return f.result
+}
+
+type exprFolder[R any] struct {
+	handlers ExprHandlers[R]
+	result   R
+}
+
+func (f *exprFolder[R]) Lit(N int) {
+	f.result = f.handlers.Lit(N)
+}
+
+func (f *exprFolder[R]) Add(Left, Right Expr) {
+	f.result = f.handlers.Add(FoldExpr(Left, f.handlers), FoldExpr(Right, f.handlers))
+}
@@ -0,0 +1,18 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package pointerfields
+
+//go:generate irgen -equal -copy -walk -seq -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+// Like in some generated ASTs, the children are pointers to interfaces.
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right *Expr)
+	Block(Stmts []*Expr)
+}
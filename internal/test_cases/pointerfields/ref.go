@@ -0,0 +1,175 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package pointerfields
+
+import "iter"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Add struct {
+	Left, Right *Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Block struct {
+	Stmts []*Expr
+}
+
+func (e *Block) FeedTo(consumer ExprConsumer) { consumer.Block(e.Stmts) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Add) Equal(other Expr) bool {
+	that, ok := other.(*Add)
+	if !ok {
+		return false
+	}
+	if !equalExprPtr(e.Left, that.Left) {
+		return false
+	}
+	if !equalExprPtr(e.Right, that.Right) {
+		return false
+	}
+	return true
+}
+
+func (e *Block) Equal(other Expr) bool {
+	that, ok := other.(*Block)
+	if !ok {
+		return false
+	}
+	if len(e.Stmts) != len(that.Stmts) {
+		return false
+	}
+	for i := range e.Stmts {
+		if !equalExprPtr(e.Stmts[i], that.Stmts[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func equalExprPtr(a, b *Expr) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return equalExpr(*a, *b)
+}
+
+func copyExpr(e Expr) Expr {
+	if c, ok := e.(interface{ Copy() Expr }); ok {
+		return c.Copy()
+	}
+	return e
+}
+
+func (e *Lit) Copy() Expr {
+	return &Lit{N: e.N}
+}
+
+func (e *Add) Copy() Expr {
+	return &Add{Left: copyExprPtr(e.Left), Right: copyExprPtr(e.Right)}
+}
+
+func (e *Block) Copy() Expr {
+	return &Block{Stmts: copyExprPtrs(e.Stmts)}
+}
+
+func copyExprPtr(e *Expr) *Expr {
+	if e == nil {
+		return nil
+	}
+	c := copyExpr(*e)
+	return &c
+}
+
+func copyExprPtrs(es []*Expr) []*Expr {
+	if es == nil {
+		return nil
+	}
+	copies := make([]*Expr, len(es))
+	for i, e := range es {
+		copies[i] = copyExprPtr(e)
+	}
+	return copies
+}
+
+func WalkExpr(e Expr, pre func(Expr) bool) {
+	if e == nil || !pre(e) {
+		return
+	}
+	switch v := e.(type) {
+	case *Add:
+		if v.Left != nil {
+			WalkExpr(*v.Left, pre)
+		}
+		if v.Right != nil {
+			WalkExpr(*v.Right, pre)
+		}
+	case *Block:
+		for _, child := range v.Stmts {
+			if child != nil {
+				WalkExpr(*child, pre)
+			}
+		}
+	}
+}
+
+func ExprAll(e Expr) iter.Seq[Expr] {
+	return func(yield func(Expr) bool) {
+		yieldExpr(e, yield)
+	}
+}
+
+func yieldExpr(e Expr, yield func(Expr) bool) bool {
+	if e == nil {
+		return true
+	}
+	if !yield(e) {
+		return false
+	}
+	switch v := e.(type) {
+	case *Add:
+		if v.Left != nil {
+			if !yieldExpr(*v.Left, yield) {
+				return false
+			}
+		}
+		if v.Right != nil {
+			if !yieldExpr(*v.Right, yield) {
+				return false
+			}
+		}
+	case *Block:
+		for _, child := range v.Stmts {
+			if child != nil {
+				if !yieldExpr(*child, yield) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
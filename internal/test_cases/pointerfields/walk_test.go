@@ -0,0 +1,70 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package pointerfields
+
+import "testing"
+
+func ref(e Expr) *Expr { return &e }
+
+// A block of 1 + 2, a nil statement and 3.
+func example() Expr {
+	return &Block{Stmts: []*Expr{
+		ref(&Add{Left: ref(&Lit{N: 1}), Right: ref(&Lit{N: 2})}),
+		nil,
+		ref(&Lit{N: 3}),
+	}}
+}
+
+func TestWalkFollowsPointers(t *testing.T) {
+	var sum, nodes int
+	WalkExpr(example(), func(e Expr) bool {
+		nodes++
+		if lit, ok := e.(*Lit); ok {
+			sum += lit.N
+		}
+		return true
+	})
+
+	if nodes != 5 || sum != 6 {
+		t.Errorf("got %d nodes summing to %d, want 5 summing to 6", nodes, sum)
+	}
+}
+
+func TestAllFollowsPointers(t *testing.T) {
+	var nodes int
+	for range ExprAll(example()) {
+		nodes++
+	}
+
+	if nodes != 5 {
+		t.Errorf("got %d nodes, want 5", nodes)
+	}
+}
+
+func TestEqualComparesPointees(t *testing.T) {
+	if !example().(*Block).Equal(example()) {
+		t.Error("equal trees with distinct pointers are not equal")
+	}
+
+	other := example()
+	*other.(*Block).Stmts[2] = &Lit{N: 4}
+	if example().(*Block).Equal(other) {
+		t.Error("trees with different literals are equal")
+	}
+}
+
+func TestCopyIsDeep(t *testing.T) {
+	original := example()
+	copied := original.(*Block).Copy()
+
+	*copied.(*Block).Stmts[2] = &Lit{N: 4}
+
+	if !original.(*Block).Equal(example()) {
+		t.Error("changing the copy changed the original")
+	}
+	if copied.(*Block).Stmts[1] != nil {
+		t.Error("a nil statement got copied as non-nil")
+	}
+}
@@ -0,0 +1,73 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package positional
+
+type Ignore struct {
+	Arg0 int
+}
+
+func (e *Ignore) FeedTo(consumer ExprConsumer) { consumer.Ignore(e.Arg0) }
+
+type Pair struct {
+	Arg0 string
+	Arg1 int
+}
+
+func (e *Pair) FeedTo(consumer ExprConsumer) { consumer.Pair(e.Arg0, e.Arg1) }
+
+type Wrap struct {
+	Arg0  Expr
+	Label string
+}
+
+func (e *Wrap) FeedTo(consumer ExprConsumer) { consumer.Wrap(e.Arg0, e.Label) }
+
+func MatchExpr(e Expr, onIgnore func(arg0 int), onPair func(arg0 string, arg1 int), onWrap func(arg0 Expr, label string)) {
+	e.FeedTo(exprMatcher{onIgnore: onIgnore, onPair: onPair, onWrap: onWrap})
+}
+
+type exprMatcher struct {
+	onIgnore func(arg0 int)
+	onPair   func(arg0 string, arg1 int)
+	onWrap   func(arg0 Expr, label string)
+}
+
+func (m exprMatcher) Ignore(Arg0 int)              { m.onIgnore(Arg0) }
+func (m exprMatcher) Pair(Arg0 string, Arg1 int)   { m.onPair(Arg0, Arg1) }
+func (m exprMatcher) Wrap(Arg0 Expr, Label string) { m.onWrap(Arg0, Label) }
+
+type BaseExprConsumer struct {
+}
+
+func (BaseExprConsumer) Ignore(Arg0 int)              {}
+func (BaseExprConsumer) Pair(Arg0 string, Arg1 int)   {}
+func (BaseExprConsumer) Wrap(Arg0 Expr, Label string) {}
+
+type ExprHandlers[R any] struct {
+	Ignore func(arg0 int) R
+	Pair   func(arg0 string, arg1 int) R
+	Wrap   func(arg0 R, label string) R
+}
+
+func FoldExpr[R any](e Expr, h ExprHandlers[R]) R {
+	f := &exprFolder[R]{handlers: h}
+	e.FeedTo(f)
+	return f.result
+}
+
+type exprFolder[R any] struct {
+	handlers ExprHandlers[R]
+	result   R
+}
+
+func (f *exprFolder[R]) Ignore(Arg0 int) {
+	f.result = f.handlers.Ignore(Arg0)
+}
+
+func (f *exprFolder[R]) Pair(Arg0 string, Arg1 int) {
+	f.result = f.handlers.Pair(Arg0, Arg1)
+}
+
+func (f *exprFolder[R]) Wrap(Arg0 Expr, Label string) {
+	f.result = f.handlers.Wrap(FoldExpr(Arg0, f.handlers), Label)
+}
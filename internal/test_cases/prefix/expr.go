@@ -0,0 +1,26 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package prefix
+
+//go:generate irgen -v -prefix Expr -constructors -out expr_ref.go Expr ExprConsumer
+//go:generate irgen -v -suffix Pattern -constructors -out pattern_ref.go Pattern PatternConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+
+type Pattern interface {
+	FeedTo(cons PatternConsumer)
+}
+
+type PatternConsumer interface {
+	Lit(N int)
+	Wildcard()
+}
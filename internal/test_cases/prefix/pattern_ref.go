@@ -0,0 +1,22 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package prefix
+
+type LitPattern struct {
+	N int
+}
+
+func (p *LitPattern) FeedTo(consumer PatternConsumer) { consumer.Lit(p.N) }
+
+type WildcardPattern struct {
+}
+
+func (p *WildcardPattern) FeedTo(consumer PatternConsumer) { consumer.Wildcard() }
+
+func MakeLitPattern(n int) Pattern {
+	return &LitPattern{N: n}
+}
+
+func MakeWildcardPattern() Pattern {
+	return &WildcardPattern{}
+}
@@ -0,0 +1,49 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package rewrite
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+func MapExpr(e Expr, f func(Expr) Expr) Expr {
+	switch v := e.(type) {
+	case *Add:
+		e = &Add{Left: MapExpr(v.Left, f), Right: MapExpr(v.Right, f)}
+	case *Call:
+		e = &Call{Fn: v.Fn, Args: mapExprs(v.Args, f)}
+	}
+	return f(e)
+}
+
+func mapExprs(es []Expr, f func(Expr) Expr) []Expr {
+	if es == nil {
+		return nil
+	}
+	mapped := make([]Expr, len(es))
+	for i, e := range es {
+		mapped[i] = MapExpr(e, f)
+	}
+	return mapped
+}
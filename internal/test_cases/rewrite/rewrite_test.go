@@ -0,0 +1,50 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package rewrite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDoubleLiterals(t *testing.T) {
+	e := &Add{
+		Left:  &Lit{N: 1},
+		Right: &Call{Fn: "f", Args: []Expr{&Var{Name: "x"}, &Lit{N: 2}}},
+	}
+
+	got := MapExpr(e, func(e Expr) Expr {
+		if lit, ok := e.(*Lit); ok {
+			return &Lit{N: 2 * lit.N}
+		}
+		return e
+	})
+
+	want := &Add{
+		Left:  &Lit{N: 2},
+		Right: &Call{Fn: "f", Args: []Expr{&Var{Name: "x"}, &Lit{N: 4}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if e.Left.(*Lit).N != 1 {
+		t.Errorf("the original expression was modified")
+	}
+}
+
+func TestMapIsBottomUp(t *testing.T) {
+	e := &Add{Left: &Lit{N: 1}, Right: &Var{Name: "x"}}
+
+	var order []string
+	MapExpr(e, func(e Expr) Expr {
+		order = append(order, reflect.TypeOf(e).Elem().Name())
+		return e
+	})
+
+	want := []string{"Lit", "Var", "Add"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("got nodes mapped in order %v, want %v", order, want)
+	}
+}
@@ -0,0 +1,18 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package sealed
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+func (*Lit) sealedExpr() {}
+func (*Add) sealedExpr() {}
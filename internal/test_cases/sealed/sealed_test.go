@@ -0,0 +1,29 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package sealed
+
+import (
+	"reflect"
+	"testing"
+)
+
+// A would-be variant declared outside of the generated code.
+type foreign struct{}
+
+func (*foreign) FeedTo(cons ExprConsumer) {}
+
+func TestForeignTypesCantImplementSealedComposite(t *testing.T) {
+	expr := reflect.TypeOf((*Expr)(nil)).Elem()
+
+	if reflect.TypeOf(&foreign{}).Implements(expr) {
+		t.Error("a type with only the FeedTo method implements Expr")
+	}
+
+	for _, variant := range []Expr{&Lit{}, &Add{}} {
+		if !reflect.TypeOf(variant).Implements(expr) {
+			t.Errorf("variant %T does not implement Expr", variant)
+		}
+	}
+}
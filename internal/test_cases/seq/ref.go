@@ -0,0 +1,51 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package seq
+
+import "iter"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Neg struct {
+	Of Expr
+}
+
+func (e *Neg) FeedTo(consumer ExprConsumer) { consumer.Neg(e.Of) }
+
+type Sum struct {
+	Terms []Expr
+}
+
+func (e *Sum) FeedTo(consumer ExprConsumer) { consumer.Sum(e.Terms) }
+
+func ExprAll(e Expr) iter.Seq[Expr] {
+	return func(yield func(Expr) bool) {
+		yieldExpr(e, yield)
+	}
+}
+
+func yieldExpr(e Expr, yield func(Expr) bool) bool {
+	if e == nil {
+		return true
+	}
+	if !yield(e) {
+		return false
+	}
+	switch v := e.(type) {
+	case *Neg:
+		if !yieldExpr(v.Of, yield) {
+			return false
+		}
+	case *Sum:
+		for _, child := range v.Terms {
+			if !yieldExpr(child, yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
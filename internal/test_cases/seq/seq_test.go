@@ -0,0 +1,50 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package seq
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAllNodesInPreOrder(t *testing.T) {
+	e := &Sum{Terms: []Expr{&Lit{N: 1}, &Neg{Of: &Lit{N: 2}}, nil}}
+
+	var got []string
+	for node := range ExprAll(e) {
+		got = append(got, fmt.Sprintf("%T", node))
+	}
+
+	want := []string{"*seq.Sum", "*seq.Lit", "*seq.Neg", "*seq.Lit"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got nodes %v, want %v", got, want)
+	}
+}
+
+func TestAllStopsOnBreak(t *testing.T) {
+	e := &Sum{Terms: []Expr{&Lit{N: 1}, &Lit{N: 2}, &Lit{N: 3}}}
+
+	var sum int
+	for node := range ExprAll(e) {
+		lit, ok := node.(*Lit)
+		if !ok {
+			continue
+		}
+		sum += lit.N
+		if lit.N == 2 {
+			break
+		}
+	}
+
+	if sum != 3 {
+		t.Errorf("got sum %d, want 3", sum)
+	}
+}
+
+func TestAllOfNil(t *testing.T) {
+	for node := range ExprAll(nil) {
+		t.Errorf("got node %#v", node)
+	}
+}
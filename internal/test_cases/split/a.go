@@ -0,0 +1,19 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package split
+
+import "go/token"
+
+//go:generate irgen -v -equal -string -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) token.Pos
+}
+
+// Operators combining expressions, consumed alongside the leaves.
+type OpConsumer interface {
+	Add(Left, Right Expr) token.Pos
+	Neg(Of Expr) token.Pos
+}
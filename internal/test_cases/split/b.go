@@ -0,0 +1,17 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package split
+
+import (
+	"go/token"
+	"time"
+)
+
+type ExprConsumer interface {
+	Lit(N int) token.Pos
+	Sleep(For time.Duration) token.Pos
+	Call(Fn string, Args []Expr) token.Pos
+	OpConsumer
+}
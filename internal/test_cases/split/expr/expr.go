@@ -0,0 +1,18 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package expr declares the interfaces, while the variants implementing them
+// are generated into package variants.
+package expr
+
+//go:generate irgen -outpkg variants -import-path github.com/szabba/irgen/internal/test_cases/split/expr -assert -verify -out ../variants/ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
@@ -0,0 +1,150 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package split
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"time"
+)
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) token.Pos { return consumer.Lit(e.N) }
+
+type Sleep struct {
+	For time.Duration
+}
+
+func (e *Sleep) FeedTo(consumer ExprConsumer) token.Pos { return consumer.Sleep(e.For) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) token.Pos { return consumer.Call(e.Fn, e.Args) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) token.Pos { return consumer.Add(e.Left, e.Right) }
+
+type Neg struct {
+	Of Expr
+}
+
+func (e *Neg) FeedTo(consumer ExprConsumer) token.Pos { return consumer.Neg(e.Of) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Sleep) Equal(other Expr) bool {
+	that, ok := other.(*Sleep)
+	if !ok {
+		return false
+	}
+	if e.For != that.For {
+		return false
+	}
+	return true
+}
+
+func (e *Call) Equal(other Expr) bool {
+	that, ok := other.(*Call)
+	if !ok {
+		return false
+	}
+	if e.Fn != that.Fn {
+		return false
+	}
+	if len(e.Args) != len(that.Args) {
+		return false
+	}
+	for i := range e.Args {
+		if !equalExpr(e.Args[i], that.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Add) Equal(other Expr) bool {
+	that, ok := other.(*Add)
+	if !ok {
+		return false
+	}
+	if !equalExpr(e.Left, that.Left) {
+		return false
+	}
+	if !equalExpr(e.Right, that.Right) {
+		return false
+	}
+	return true
+}
+
+func (e *Neg) Equal(other Expr) bool {
+	that, ok := other.(*Neg)
+	if !ok {
+		return false
+	}
+	if !equalExpr(e.Of, that.Of) {
+		return false
+	}
+	return true
+}
+
+func stringExpr(e Expr) string {
+	if s, ok := e.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+func (e *Lit) String() string {
+	return fmt.Sprintf("Lit{N: %v}", e.N)
+}
+
+func (e *Sleep) String() string {
+	return fmt.Sprintf("Sleep{For: %v}", e.For)
+}
+
+func (e *Call) String() string {
+	return fmt.Sprintf("Call{Fn: %v, Args: %s}", e.Fn, stringExprs(e.Args))
+}
+
+func (e *Add) String() string {
+	return fmt.Sprintf("Add{Left: %s, Right: %s}", stringExpr(e.Left), stringExpr(e.Right))
+}
+
+func (e *Neg) String() string {
+	return fmt.Sprintf("Neg{Of: %s}", stringExpr(e.Of))
+}
+
+func stringExprs(es []Expr) string {
+	parts := make([]string, len(es))
+	for i, e := range es {
+		parts[i] = stringExpr(e)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
@@ -0,0 +1,35 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package variants
+
+import (
+	"testing"
+
+	"github.com/szabba/irgen/internal/test_cases/split/expr"
+)
+
+type evaluator struct {
+	result int
+}
+
+func (ev *evaluator) Lit(N int) { ev.result = N }
+
+func (ev *evaluator) Add(Left, Right expr.Expr) {
+	Left.FeedTo(ev)
+	left := ev.result
+	Right.FeedTo(ev)
+	ev.result += left
+}
+
+func TestVariantsImplementComposite(t *testing.T) {
+	var e expr.Expr = &Add{Left: &Lit{N: 3}, Right: &Lit{N: 4}}
+
+	ev := &evaluator{}
+	e.FeedTo(ev)
+
+	if ev.result != 7 {
+		t.Errorf("got %d, want 7", ev.result)
+	}
+}
@@ -0,0 +1,22 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package variants
+
+import "github.com/szabba/irgen/internal/test_cases/split/expr"
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer expr.ExprConsumer) { consumer.Lit(e.N) }
+
+type Add struct {
+	Left, Right expr.Expr
+}
+
+func (e *Add) FeedTo(consumer expr.ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+var (
+	_ expr.Expr = (*Lit)(nil)
+	_ expr.Expr = (*Add)(nil)
+)
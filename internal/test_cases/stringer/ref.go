@@ -0,0 +1,73 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package stringer
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+type Nil struct {
+}
+
+func (e *Nil) FeedTo(consumer ExprConsumer) { consumer.Nil() }
+
+func stringExpr(e Expr) string {
+	if s, ok := e.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+func (e *Lit) String() string {
+	return fmt.Sprintf("Lit{N: %v}", e.N)
+}
+
+func (e *Var) String() string {
+	return fmt.Sprintf("Var{Name: %v}", e.Name)
+}
+
+func (e *Add) String() string {
+	return fmt.Sprintf("Add{Left: %s, Right: %s}", stringExpr(e.Left), stringExpr(e.Right))
+}
+
+func (e *Call) String() string {
+	return fmt.Sprintf("Call{Fn: %v, Args: %s}", e.Fn, stringExprs(e.Args))
+}
+
+func (e *Nil) String() string {
+	return "Nil{}"
+}
+
+func stringExprs(es []Expr) string {
+	parts := make([]string, len(es))
+	for i, e := range es {
+		parts[i] = stringExpr(e)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
@@ -0,0 +1,31 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package stringer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	e := &Call{Fn: "f", Args: []Expr{
+		&Add{Left: &Lit{N: 1}, Right: &Var{Name: "x"}},
+		&Nil{},
+	}}
+
+	want := "Call{Fn: f, Args: [Add{Left: Lit{N: 1}, Right: Var{Name: x}} Nil{}]}"
+	if got := fmt.Sprint(e); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStringWithNilField(t *testing.T) {
+	e := &Add{Left: &Lit{N: 1}}
+
+	want := "Add{Left: Lit{N: 1}, Right: <nil>}"
+	if got := e.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,25 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package tagmethod
+
+type ExprLit struct {
+	N int
+}
+
+func (e *ExprLit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type ExprVar struct {
+	Name string
+}
+
+func (e *ExprVar) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type ExprAdd struct {
+	Left, Right Expr
+}
+
+func (e *ExprAdd) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+func (*ExprLit) Tag() string { return "ExprLit" }
+func (*ExprVar) Tag() string { return "ExprVar" }
+func (*ExprAdd) Tag() string { return "ExprAdd" }
@@ -0,0 +1,22 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tagmethod
+
+import "testing"
+
+func TestTag(t *testing.T) {
+	for _, tt := range []struct {
+		e    Expr
+		want string
+	}{
+		{&ExprLit{N: 1}, "ExprLit"},
+		{&ExprVar{Name: "x"}, "ExprVar"},
+		{&ExprAdd{Left: &ExprLit{}, Right: &ExprLit{}}, "ExprAdd"},
+	} {
+		if got := tt.e.Tag(); got != tt.want {
+			t.Errorf("got tag %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tags
+
+//go:generate irgen -v -out ref.go Event EventConsumer
+
+type Event interface {
+	FeedTo(cons EventConsumer)
+}
+
+type EventConsumer interface {
+	// A mouse click at some point.
+	//irgen:tag X json:"x"
+	//irgen:tag Y json:"y"
+	Click(X, Y int)
+
+	Key(Code rune, Name string) //irgen:tag Name json:"name,omitempty"
+
+	Close()
+}
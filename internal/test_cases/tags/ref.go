@@ -0,0 +1,23 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package tags
+
+// A mouse click at some point.
+type Click struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func (e *Click) FeedTo(consumer EventConsumer) { consumer.Click(e.X, e.Y) }
+
+type Key struct {
+	Code rune
+	Name string `json:"name,omitempty"`
+}
+
+func (e *Key) FeedTo(consumer EventConsumer) { consumer.Key(e.Code, e.Name) }
+
+type Close struct {
+}
+
+func (e *Close) FeedTo(consumer EventConsumer) { consumer.Close() }
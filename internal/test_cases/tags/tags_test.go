@@ -0,0 +1,29 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package tags
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTagsApplyToJSON(t *testing.T) {
+	for _, tc := range []struct {
+		event Event
+		want  string
+	}{
+		{&Click{X: 1, Y: 2}, `{"x":1,"y":2}`},
+		{&Key{Code: 'q', Name: "Q"}, `{"Code":113,"name":"Q"}`},
+		{&Key{Code: 'q'}, `{"Code":113}`},
+	} {
+		got, err := json.Marshal(tc.event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("got %s, want %s", got, tc.want)
+		}
+	}
+}
@@ -6,9 +6,11 @@ type Named struct {
 	Name string
 	Args []Type
 }
+
+func (t *Named) FeedTo(consumer TypeConsumer) { consumer.Named(t.Name, t.Args) }
+
 type Function struct {
 	Arg, Output Type
 }
 
-func (Type *Named) FeedTo(consumer TypeConsumer)    { consumer.Named(Type.Name, Type.Args) }
-func (Type *Function) FeedTo(consumer TypeConsumer) { consumer.Function(Type.Arg, Type.Output) }
+func (t *Function) FeedTo(consumer TypeConsumer) { consumer.Function(t.Arg, t.Output) }
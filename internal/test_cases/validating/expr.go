@@ -0,0 +1,29 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package validating
+
+import "errors"
+
+//go:generate irgen -validate -verify -out ref.go Expr ExprConsumer
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Var(Name string)
+	Add(Left, Right Expr)
+}
+
+var errNegative = errors.New("negative literal")
+
+// Only literals have an invariant to check.
+func validateLit(v *Lit) error {
+	if v.N < 0 {
+		return errNegative
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package validating
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+func NewLit(n int) (Expr, error) {
+	v := &Lit{N: n}
+	if err := validateLit(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func NewVar(name string) (Expr, error) {
+	return &Var{Name: name}, nil
+}
+
+func NewAdd(left Expr, right Expr) (Expr, error) {
+	return &Add{Left: left, Right: right}, nil
+}
@@ -0,0 +1,43 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package validating
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidatorRejects(t *testing.T) {
+	e, err := NewLit(-1)
+
+	if !errors.Is(err, errNegative) {
+		t.Errorf("got error %v, want %v", err, errNegative)
+	}
+	if e != nil {
+		t.Errorf("got %#v along with the error, want nil", e)
+	}
+}
+
+func TestValidatorAccepts(t *testing.T) {
+	e, err := NewLit(3)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lit, ok := e.(*Lit); !ok || lit.N != 3 {
+		t.Errorf("got %#v, want &Lit{N: 3}", e)
+	}
+}
+
+func TestNoValidator(t *testing.T) {
+	e, err := NewVar("x")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := e.(*Var); !ok || v.Name != "x" {
+		t.Errorf("got %#v, want &Var{Name: \"x\"}", e)
+	}
+}
@@ -0,0 +1,226 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package value
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Lit struct {
+	N int
+}
+
+func (e Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+var (
+	_ Expr = Lit{}
+	_ Expr = Var{}
+	_ Expr = Add{}
+)
+
+func MakeLit(n int) Expr {
+	return Lit{N: n}
+}
+
+func MakeVar(name string) Expr {
+	return Var{Name: name}
+}
+
+func MakeAdd(left, right Expr) Expr {
+	return Add{Left: left, Right: right}
+}
+
+func NewLit(n int) Lit {
+	return Lit{N: n}
+}
+
+func NewVar(name string) Var {
+	return Var{Name: name}
+}
+
+func NewAdd(left, right Expr) Add {
+	return Add{Left: left, Right: right}
+}
+
+func (Lit) sealedExpr() {}
+func (Var) sealedExpr() {}
+func (Add) sealedExpr() {}
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e Lit) Equal(other Expr) bool {
+	that, ok := other.(Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e Var) Equal(other Expr) bool {
+	that, ok := other.(Var)
+	if !ok {
+		return false
+	}
+	if e.Name != that.Name {
+		return false
+	}
+	return true
+}
+
+func (e Add) Equal(other Expr) bool {
+	that, ok := other.(Add)
+	if !ok {
+		return false
+	}
+	if !equalExpr(e.Left, that.Left) {
+		return false
+	}
+	if !equalExpr(e.Right, that.Right) {
+		return false
+	}
+	return true
+}
+
+func stringExpr(e Expr) string {
+	if s, ok := e.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+func (e Lit) String() string {
+	return fmt.Sprintf("Lit{N: %v}", e.N)
+}
+
+func (e Var) String() string {
+	return fmt.Sprintf("Var{Name: %v}", e.Name)
+}
+
+func (e Add) String() string {
+	return fmt.Sprintf("Add{Left: %s, Right: %s}", stringExpr(e.Left), stringExpr(e.Right))
+}
+
+func (e Lit) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		N    int
+	}{"Lit", e.N})
+}
+
+func (e Var) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Name string
+	}{"Var", e.Name})
+}
+
+func (e Add) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type  string `json:"type"`
+		Left  Expr
+		Right Expr
+	}{"Add", e.Left, e.Right})
+}
+
+type ExprJSON struct {
+	Expr Expr
+}
+
+func (w ExprJSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.Expr)
+}
+
+func (w *ExprJSON) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		w.Expr = nil
+		return nil
+	}
+
+	var tagged struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(data, &tagged)
+	if err != nil {
+		return err
+	}
+
+	switch tagged.Type {
+	case "Lit":
+		var v struct {
+			N int
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = Lit{N: v.N}
+	case "Var":
+		var v struct {
+			Name string
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = Var{Name: v.Name}
+	case "Add":
+		var v struct {
+			Left  ExprJSON
+			Right ExprJSON
+		}
+		err := json.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+		w.Expr = Add{Left: v.Left.Expr, Right: v.Right.Expr}
+	default:
+		return fmt.Errorf("unknown Expr variant %q", tagged.Type)
+	}
+	return nil
+}
+
+type ExprKind int
+
+const (
+	LitKind ExprKind = iota
+	VarKind
+	AddKind
+)
+
+func (e Lit) Kind() ExprKind { return LitKind }
+func (e Var) Kind() ExprKind { return VarKind }
+func (e Add) Kind() ExprKind { return AddKind }
+
+func (k ExprKind) String() string {
+	switch k {
+	case LitKind:
+		return "Lit"
+	case VarKind:
+		return "Var"
+	case AddKind:
+		return "Add"
+	}
+	return fmt.Sprintf("ExprKind(%d)", int(k))
+}
@@ -0,0 +1,31 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package value
+
+import "testing"
+
+func TestVariantValuesImplementTheComposite(t *testing.T) {
+	var e Expr = Add{Left: Lit{N: 1}, Right: Var{Name: "x"}}
+
+	if _, ok := e.(Add); !ok {
+		t.Errorf("got %T, want Add", e)
+	}
+	if got := MakeLit(1); got != (Lit{N: 1}) {
+		t.Errorf("got %v, want Lit{N: 1}", got)
+	}
+}
+
+func TestVariantValuesAreMapKeys(t *testing.T) {
+	seen := map[Expr]bool{
+		MakeAdd(NewLit(1), NewVar("x")): true,
+	}
+
+	if !seen[Add{Left: Lit{N: 1}, Right: Var{Name: "x"}}] {
+		t.Error("an equal variant value is not found in the map")
+	}
+	if seen[Add{Left: Lit{N: 2}, Right: Var{Name: "x"}}] {
+		t.Error("a different variant value is found in the map")
+	}
+}
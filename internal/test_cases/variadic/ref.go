@@ -0,0 +1,171 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package variadic
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Seq struct {
+	Exprs []Expr
+}
+
+func (e *Seq) FeedTo(consumer ExprConsumer) { consumer.Seq(e.Exprs...) }
+
+type Call struct {
+	Fn   string
+	Args []int
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args...) }
+
+func MakeLit(n int) Expr {
+	return &Lit{N: n}
+}
+
+func MakeSeq(exprs []Expr) Expr {
+	return &Seq{Exprs: exprs}
+}
+
+func MakeCall(fn string, args []int) Expr {
+	return &Call{Fn: fn, Args: args}
+}
+
+func MatchExpr(e Expr, onLit func(n int), onSeq func(exprs ...Expr), onCall func(fn string, args ...int)) {
+	e.FeedTo(exprMatcher{onLit: onLit, onSeq: onSeq, onCall: onCall})
+}
+
+type exprMatcher struct {
+	onLit  func(n int)
+	onSeq  func(exprs ...Expr)
+	onCall func(fn string, args ...int)
+}
+
+func (m exprMatcher) Lit(N int)                   { m.onLit(N) }
+func (m exprMatcher) Seq(Exprs ...Expr)           { m.onSeq(Exprs...) }
+func (m exprMatcher) Call(Fn string, Args ...int) { m.onCall(Fn, Args...) }
+
+func equalExpr(a, b Expr) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	eq, ok := a.(interface{ Equal(Expr) bool })
+	return ok && eq.Equal(b)
+}
+
+func (e *Lit) Equal(other Expr) bool {
+	that, ok := other.(*Lit)
+	if !ok {
+		return false
+	}
+	if e.N != that.N {
+		return false
+	}
+	return true
+}
+
+func (e *Seq) Equal(other Expr) bool {
+	that, ok := other.(*Seq)
+	if !ok {
+		return false
+	}
+	if len(e.Exprs) != len(that.Exprs) {
+		return false
+	}
+	for i := range e.Exprs {
+		if !equalExpr(e.Exprs[i], that.Exprs[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Call) Equal(other Expr) bool {
+	that, ok := other.(*Call)
+	if !ok {
+		return false
+	}
+	if e.Fn != that.Fn {
+		return false
+	}
+	if len(e.Args) != len(that.Args) {
+		return false
+	}
+	for i := range e.Args {
+		if e.Args[i] != that.Args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func stringExpr(e Expr) string {
+	if s, ok := e.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+func (e *Lit) String() string {
+	return fmt.Sprintf("Lit{N: %v}", e.N)
+}
+
+func (e *Seq) String() string {
+	return fmt.Sprintf("Seq{Exprs: %s}", stringExprs(e.Exprs))
+}
+
+func (e *Call) String() string {
+	return fmt.Sprintf("Call{Fn: %v, Args: %v}", e.Fn, e.Args)
+}
+
+func stringExprs(es []Expr) string {
+	parts := make([]string, len(es))
+	for i, e := range es {
+		parts[i] = stringExpr(e)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+type ExprHandlers[R any] struct {
+	Lit  func(n int) R
+	Seq  func(exprs []R) R
+	Call func(fn string, args []int) R
+}
+
+func FoldExpr[R any](e Expr, h ExprHandlers[R]) R {
+	f := &exprFolder[R]{handlers: h}
+	e.FeedTo(f)
+	return f.result
+}
+
+type exprFolder[R any] struct {
+	handlers ExprHandlers[R]
+	result   R
+}
+
+func (f *exprFolder[R]) Lit(N int) {
+	f.result = f.handlers.Lit(N)
+}
+
+func (f *exprFolder[R]) Seq(Exprs ...Expr) {
+	f.result = f.handlers.Seq(foldExprs(Exprs, f.handlers))
+}
+
+func (f *exprFolder[R]) Call(Fn string, Args ...int) {
+	f.result = f.handlers.Call(Fn, Args)
+}
+
+func foldExprs[R any](es []Expr, h ExprHandlers[R]) []R {
+	rs := make([]R, len(es))
+	for i, e := range es {
+		rs[i] = FoldExpr(e, h)
+	}
+	return rs
+}
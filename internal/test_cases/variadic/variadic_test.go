@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package variadic
+
+import "testing"
+
+type recorder struct {
+	exprs []Expr
+	args  []int
+}
+
+func (r *recorder) Lit(N int)                   {}
+func (r *recorder) Seq(Exprs ...Expr)           { r.exprs = Exprs }
+func (r *recorder) Call(Fn string, Args ...int) { r.args = Args }
+
+func TestVariadicArgumentsAreSpread(t *testing.T) {
+	var r recorder
+
+	seq := &Seq{Exprs: []Expr{&Lit{N: 1}, &Lit{N: 2}}}
+	seq.FeedTo(&r)
+	if len(r.exprs) != 2 {
+		t.Errorf("got %d expressions, want 2", len(r.exprs))
+	}
+
+	call := &Call{Fn: "max", Args: []int{1, 2, 3}}
+	call.FeedTo(&r)
+	if len(r.args) != 3 {
+		t.Errorf("got %d arguments, want 3", len(r.args))
+	}
+}
+
+func TestVariadicMatch(t *testing.T) {
+	var count int
+	MatchExpr(&Seq{Exprs: []Expr{&Lit{}, &Lit{}}},
+		func(n int) {},
+		func(exprs ...Expr) { count = len(exprs) },
+		func(fn string, args ...int) {})
+
+	if count != 2 {
+		t.Errorf("got %d expressions, want 2", count)
+	}
+}
+
+func TestVariadicFold(t *testing.T) {
+	sum := ExprHandlers[int]{
+		Lit: func(n int) int { return n },
+		Seq: func(exprs []int) int {
+			total := 0
+			for _, e := range exprs {
+				total += e
+			}
+			return total
+		},
+		Call: func(fn string, args []int) int { return len(args) },
+	}
+
+	e := MakeSeq([]Expr{MakeLit(1), MakeCall("f", []int{1, 2}), MakeLit(3)})
+	if got := FoldExpr(e, sum); got != 6 {
+		t.Errorf("got %d, want %d", got, 6)
+	}
+}
@@ -0,0 +1,43 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package walk
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Var struct {
+	Name string
+}
+
+func (e *Var) FeedTo(consumer ExprConsumer) { consumer.Var(e.Name) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+func WalkExpr(e Expr, pre func(Expr) bool) {
+	if e == nil || !pre(e) {
+		return
+	}
+	switch v := e.(type) {
+	case *Add:
+		WalkExpr(v.Left, pre)
+		WalkExpr(v.Right, pre)
+	case *Call:
+		for _, child := range v.Args {
+			WalkExpr(child, pre)
+		}
+	}
+}
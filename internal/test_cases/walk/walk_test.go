@@ -0,0 +1,42 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package walk
+
+import "testing"
+
+// f(1 + x, g(2), nil)
+var tree = &Call{
+	Fn: "f",
+	Args: []Expr{
+		&Add{Left: &Lit{N: 1}, Right: &Var{Name: "x"}},
+		&Call{Fn: "g", Args: []Expr{&Lit{N: 2}}},
+		nil,
+	},
+}
+
+func TestWalkCountsNodes(t *testing.T) {
+	nodes := 0
+	WalkExpr(tree, func(Expr) bool {
+		nodes++
+		return true
+	})
+
+	if nodes != 6 {
+		t.Errorf("got %d nodes, want 6", nodes)
+	}
+}
+
+func TestWalkSkipsChildren(t *testing.T) {
+	nodes := 0
+	WalkExpr(tree, func(e Expr) bool {
+		nodes++
+		_, isAdd := e.(*Add)
+		return !isAdd
+	})
+
+	if nodes != 4 {
+		t.Errorf("got %d nodes, want 4", nodes)
+	}
+}
@@ -0,0 +1,41 @@
+// Code generated by irgen; DO NOT EDIT.
+
+package walkparent
+
+type Lit struct {
+	N int
+}
+
+func (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }
+
+type Add struct {
+	Left, Right Expr
+}
+
+func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }
+
+type Call struct {
+	Fn   string
+	Args []Expr
+}
+
+func (e *Call) FeedTo(consumer ExprConsumer) { consumer.Call(e.Fn, e.Args) }
+
+func WalkExprWithParent(e Expr, pre func(node, parent Expr) bool) {
+	walkExprWithParent(e, nil, pre)
+}
+
+func walkExprWithParent(e, parent Expr, pre func(node, parent Expr) bool) {
+	if e == nil || !pre(e, parent) {
+		return
+	}
+	switch v := e.(type) {
+	case *Add:
+		walkExprWithParent(v.Left, e, pre)
+		walkExprWithParent(v.Right, e, pre)
+	case *Call:
+		for _, child := range v.Args {
+			walkExprWithParent(child, e, pre)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package walkparent
+
+import (
+	"fmt"
+	"testing"
+)
+
+func name(e Expr) string {
+	switch e := e.(type) {
+	case nil:
+		return "nil"
+	case *Lit:
+		return fmt.Sprint(e.N)
+	case *Add:
+		return "+"
+	case *Call:
+		return e.Fn
+	}
+	return "?"
+}
+
+func TestWalkWithParent(t *testing.T) {
+	// f(1 + 2, 3)
+	e := &Call{Fn: "f", Args: []Expr{&Add{Left: &Lit{N: 1}, Right: &Lit{N: 2}}, &Lit{N: 3}}}
+
+	var got []string
+	WalkExprWithParent(e, func(node, parent Expr) bool {
+		got = append(got, name(parent)+" -> "+name(node))
+		return true
+	})
+
+	want := []string{"nil -> f", "f -> +", "+ -> 1", "+ -> 2", "f -> 3"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestWalkWithParentSkipsChildren(t *testing.T) {
+	e := &Add{Left: &Add{Left: &Lit{N: 1}, Right: &Lit{N: 2}}, Right: &Lit{N: 3}}
+
+	var got []string
+	WalkExprWithParent(e, func(node, parent Expr) bool {
+		got = append(got, name(parent)+" -> "+name(node))
+		return parent == nil
+	})
+
+	want := []string{"nil -> +", "+ -> +", "+ -> 3"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
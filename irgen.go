@@ -5,14 +5,24 @@
 package irgen
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"go/ast"
+	"go/build/constraint"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
-
-	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
 )
 
 // A configuration object specifying what to generate based on what.
@@ -22,108 +32,1216 @@ type Config struct {
 	//
 	PackageName string
 
-	TypeNames struct {
-		Composite string
-		Consumer  string
+	// The files of the source package to parse, relative to Directory. When
+	// empty, all the files in the directory are parsed. Everything irgen
+	// needs (like the composite and consumer types) has to be declared in
+	// them.
+	Files []string
+
+	// The name of the package the generated code belongs to, if it's not
+	// the source package. The source package's types are then referred to
+	// through an import.
+	OutputPackageName string
+
+	// The import path of the source package, for generating the code into
+	// another package that refers to the composite and consumer through an
+	// import. When empty, it's worked out from Directory, within a module or
+	// GOPATH, if OutputPackageName is set.
+	CompositeImportPath string
+
+	TypeNames TypeNames
+
+	// Further composite/consumer pairs to generate code for, after the one
+	// named by TypeNames. All of it ends up in one file.
+	Pairs []TypeNames
+
+	// The name of the generated dispatch method. It has to be the name of
+	// the destructuring method of the composite, so that the variants
+	// implement it -- setting it guards against the interface drifting away
+	// from what the go:generate line says. When empty, whatever name the
+	// composite uses is taken.
+	MethodName string
+
+	// The type of an accumulator threaded through the destructuring method,
+	// eg. int for a method like FeedTo(cons ExprConsumer, acc *int). Each
+	// consumer method then takes the pointer as its last argument, which the
+	// variants pass on and which isn't a variant field.
+	AccumulatorType string
+
+	// Whether consumer methods have to name all their arguments. Otherwise
+	// the unnamed and blank (_) ones get field names made from their
+	// position, eg. Arg0.
+	StrictNames bool
+
+	// Whether the visitor is fallible: the destructuring method and each
+	// consumer method then have to return exactly an error, which the
+	// variants pass on to the caller.
+	FallibleVisitor bool
+
+	// Whether to declare each variant field on a line of its own, even when
+	// the consumer method declares the parameters together, like Left,
+	// Right Expr. Diffs of the output then show which field changed.
+	ExpandGroups bool
+
+	// Whether to generate only the variant types, without the methods
+	// implementing the composite, eg. for dispatching on them by hand. None
+	// of the optional features can be generated then.
+	TypesOnly bool
+
+	// Whether to generate only the methods implementing the composite, for
+	// variant types the source package declares by hand, eg. to add fields
+	// or tags. They have to be structs with the fields the variants would
+	// have.
+	MethodsOnly bool
+
+	// A text/template producing the body of the method each variant
+	// implements the composite with, instead of the call passing it to the
+	// consumer. It's executed with a MethodBodyData. The result has to be
+	// valid statements.
+	MethodBodyTemplate string
+
+	// Whether the methods generated for the variants have value receivers,
+	// rather than pointer ones. The variants themselves, not only pointers
+	// to them, then implement the composite, and constructors return them
+	// by value.
+	ValueReceiver bool
+
+	// The name of the receiver in the methods generated for the variants.
+	// When empty, it's the first letter of the composite type name,
+	// lowercased, with underscores appended if it collides with anything.
+	ReceiverName string
+
+	// Whether to generate compile-time assertions that the variants
+	// implement the composite, so that the code stops compiling as soon as
+	// they drift apart.
+	EmitAssertions bool
+
+	// Whether to list the variants of each composite in an //irgen:variants
+	// directive on the first of them, for editor plugins and linters
+	// checking that switches over the composite are exhaustive.
+	EmitVariantManifest bool
+
+	// Whether to generate a MakeX function for each variant X, returning it
+	// as the composite type.
+	Constructors bool
+
+	// Whether to generate a NewX function for each variant X, returning a
+	// pointer to it. This can go along with Constructors.
+	ConcreteConstructors bool
+
+	// Whether to generate a NewX function for each variant X returning it
+	// as the composite type along with an error. When the source package
+	// declares a validateX function taking the variant and returning an
+	// error, the constructor returns what it does. This can't go along with
+	// ConcreteConstructors, which names its functions the same.
+	ValidatingConstructors bool
+
+	// Whether to generate a MatchX function for the composite type X, taking
+	// a value and one handler function per variant.
+	GenerateMatch bool
+
+	// Whether to generate a BaseX struct for the consumer type X, with
+	// methods doing nothing. It can be embedded to implement the consumer
+	// while overriding only some of the methods.
+	GenerateBase bool
+
+	// Whether to generate an Equal method on each variant, comparing it
+	// structurally with another value of the composite type.
+	GenerateEqual bool
+
+	// Whether to generate a Copy method on each variant, returning a deep
+	// copy of it as the composite type.
+	GenerateCopy bool
+
+	// Whether to generate a Hash method on each variant, returning an FNV
+	// hash of it that's the same for values that are Equal.
+	GenerateHash bool
+
+	// Whether to generate a String method on each variant, rendering it like
+	// a keyed composite literal.
+	GenerateString bool
+
+	// Whether to generate a FoldX function for the composite type X, folding
+	// a value into a result with a struct of handlers, one per variant.
+	GenerateFold bool
+
+	// Whether to generate a WalkX function for the composite type X, calling
+	// a function on every node of a tree of composite values.
+	GenerateWalk bool
+
+	// Whether to generate a WalkXWithParent function for the composite type
+	// X, like WalkX but passing the parent of each node along with it (nil
+	// for the root).
+	GenerateWalkParent bool
+
+	// Whether to generate a MapX function for the composite type X,
+	// rebuilding a tree of composite values with a function applied to every
+	// node, bottom-up.
+	GenerateMap bool
+
+	// Whether to generate an XAll function for the composite type X,
+	// returning an iterator over every node of a tree of composite values,
+	// in pre-order.
+	GenerateSeq bool
+
+	// Whether to generate an XKind enum for the composite type X, with
+	// a constant per variant and a Kind method on each variant returning it.
+	GenerateKind bool
+
+	// Whether to generate a Tag method on each variant, returning the
+	// variant name, eg. for logging.
+	GenerateTag bool
+
+	// Whether to generate JSON marshalling for the variants, and a wrapper
+	// type unmarshalling any of them. The variant is told by a "type" field.
+	GenerateJSON bool
+
+	// Whether to seal the composite, so that no types other than the
+	// variants can implement it. Each variant gets an unexported marker
+	// method -- either the one the composite interface already declares,
+	// or isX for a composite named X, which should then be added to the
+	// interface.
+	Sealed bool
+
+	// The license notice to put at the very top of the generated file. It's
+	// either a shortcut for a known notice (mpl2 or apache2), or an SPDX
+	// license expression, like MIT OR Apache-2.0, which gets an
+	// SPDX-License-Identifier line.
+	LicenseHeader string
+
+	// Text to put at the top of the generated file, like a license banner.
+	// Lines that aren't comments already get commented out. The usual
+	// generated code marker follows it.
+	Header string
+
+	// Whether to leave out the "// Code generated ... DO NOT EDIT." marker,
+	// eg. for output that gets embedded in another generated file. Linters
+	// and editors tell generated code by the marker, so without one it's
+	// treated like code written by hand.
+	OmitHeader bool
+
+	// Whether to add line directives pointing the fields of each variant at
+	// the consumer method they come from, so that compiler errors about them
+	// refer to the source instead of the generated file.
+	LineDirectives bool
+
+	// The name of the file the generated code goes to, relative to
+	// Directory. Line directives use it to switch back to the generated
+	// file. When empty, it's the DefaultOutputName.
+	OutputFile string
+
+	// Build constraints the generated file should be subject to, all of
+	// which have to be satisfied. Each is a build tag, possibly negated, or
+	// a more involved //go:build expression.
+	BuildTags []string
+
+	// Linters to silence on the generated declarations, through a
+	// //nolint:<names> comment on each of them. That's for linters that
+	// don't skip generated files. None are silenced by default.
+	LintSuppressions []string
+
+	// Whether to only parse and check the source, describing what would be
+	// generated instead of generating it. No code gets output then, but the
+	// errors are the same as they would be otherwise -- except for the ones
+	// Verify finds, since there's no code to type check.
+	DryRun bool
+
+	// Where dry runs describe what would be generated. It's os.Stderr when
+	// nil.
+	Summary io.Writer
+
+	// Whether to type check the generated code together with the source
+	// package, failing instead of returning code that does not compile.
+	Verify bool
+
+	// Called to report what the generator is doing, like log.Printf. Nothing
+	// is reported when it's nil.
+	Logf func(format string, args ...interface{})
+
+	// Called with the generated file before it's printed, to change it or add
+	// declarations to it, like an init function registering the variants.
+	// The file has to stay valid: any imports the new declarations need have
+	// to be added to its import declaration, which comes first when there is
+	// one. Returning an error stops the generation.
+	PostProcess func(file *ast.File) error
+}
+
+// Errors callers can tell apart with errors.Is. The errors returned give more
+// detail, like the position and the names involved.
+var (
+	// ErrNotInterface is returned when the composite or the consumer type
+	// (or an interface embedded in it) is not an interface.
+	ErrNotInterface = errors.New("not an interface")
+
+	// ErrMethodCount is returned when the composite doesn't have exactly
+	// one method taking the consumer.
+	ErrMethodCount = errors.New("wrong number of destructuring methods")
+)
+
+// The names of a composite type and the consumer type describing its variants.
+type TypeNames struct {
+	Composite string
+	Consumer  string
+
+	// Added around the consumer method names to get the variant type
+	// names, eg. so that Lit becomes ExprLit. This avoids collisions
+	// when several composites in a package have like-named variants.
+	VariantPrefix, VariantSuffix string
+}
+
+// pairs lists all the composite/consumer pairs to generate code for.
+func (cfg Config) pairs() []TypeNames {
+	var pairs []TypeNames
+	if cfg.TypeNames.Composite != "" || cfg.TypeNames.Consumer != "" {
+		pairs = append(pairs, cfg.TypeNames)
 	}
+	return append(pairs, cfg.Pairs...)
 }
 
+// PerComposite splits the config into one for each composite/consumer pair,
+// so that the code for each composite can go to a file of its own. The output
+// file is left to the default, named after the composite.
+func (cfg Config) PerComposite() []Config {
+	var configs []Config
+	for _, names := range cfg.pairs() {
+		single := cfg
+		single.TypeNames, single.Pairs = names, nil
+		single.OutputFile = ""
+		configs = append(configs, single)
+	}
+	return configs
+}
+
+// OutputFileName is the name of the file the code generated for a composite
+// goes to by default, eg. expr_impl.go for Expr.
+func OutputFileName(composite string) string {
+	return strings.ToLower(composite) + "_impl.go"
+}
+
+// DefaultOutputName is the name of the file the code goes to when the config
+// doesn't name one: OutputFileName of the first composite. It's empty when
+// there are no composite/consumer pairs.
+func (cfg Config) DefaultOutputName() string {
+	pairs := cfg.pairs()
+	if len(pairs) == 0 {
+		return ""
+	}
+	return OutputFileName(pairs[0].Composite)
+}
+
+// Validate checks that the config names everything needed to generate code,
+// without parsing the source. All the problems found are reported together.
+func (cfg Config) Validate() error {
+	var problems []string
+
+	switch info, err := os.Stat(cfg.Directory); {
+	case cfg.Directory == "":
+		problems = append(problems, "no directory given")
+	case err != nil:
+		problems = append(problems, fmt.Sprintf("directory %q can't be read: %s", cfg.Directory, err))
+	case !info.IsDir():
+		problems = append(problems, fmt.Sprintf("%q is not a directory", cfg.Directory))
+	}
+
+	if cfg.PackageName == "" {
+		problems = append(problems, "no package name given")
+	}
+
+	if strings.ContainsAny(cfg.LicenseHeader, "\r\n") {
+		problems = append(problems, fmt.Sprintf("license %q is not a single line", cfg.LicenseHeader))
+	}
+
+	for _, name := range cfg.LintSuppressions {
+		if !isLinterName(name) {
+			problems = append(problems, fmt.Sprintf("lint suppression %q is not a linter name", name))
+		}
+	}
+
+	if cfg.ReceiverName != "" && !token.IsIdentifier(cfg.ReceiverName) {
+		problems = append(problems, fmt.Sprintf("receiver name %q is not an identifier", cfg.ReceiverName))
+	}
+
+	if cfg.AccumulatorType != "" {
+		_, err := parser.ParseExpr(cfg.AccumulatorType)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("accumulator type %q is not a type: %s", cfg.AccumulatorType, err))
+		}
+		if cfg.GenerateMatch || cfg.GenerateFold {
+			problems = append(problems, "match and fold functions can't be generated with an accumulator")
+		}
+	}
+
+	if cfg.TypesOnly && cfg.generatesMoreThanTypes() {
+		problems = append(problems, "nothing besides the variant types can be generated when only the types are")
+	}
+
+	if cfg.MethodBodyTemplate != "" {
+		_, err := template.New("body").Parse(cfg.MethodBodyTemplate)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("method body template can't be parsed: %s", err))
+		}
+		if cfg.TypesOnly {
+			problems = append(problems, "the method body template can't be used when only the types are generated")
+		}
+	}
+
+	if cfg.MethodsOnly && cfg.TypesOnly {
+		problems = append(problems, "only the types and only the methods can't both be generated")
+	}
+	if cfg.MethodsOnly && cfg.EmitVariantManifest {
+		problems = append(problems, "the variant manifest can't be generated without the variant types")
+	}
+
+	if cfg.ConcreteConstructors && cfg.ValidatingConstructors {
+		problems = append(problems, "concrete and validating constructors can't both be generated, since they're named the same")
+	}
+
+	pairs := cfg.pairs()
+	if len(pairs) == 0 {
+		problems = append(problems, "no composite/consumer pairs given")
+	}
+	for i, names := range pairs {
+		if names.Composite == "" {
+			problems = append(problems, fmt.Sprintf("pair %d has no composite type name", i+1))
+		}
+		if names.Consumer == "" {
+			problems = append(problems, fmt.Sprintf("pair %d has no consumer type name", i+1))
+		}
+		if names.Composite != "" && names.Composite == names.Consumer {
+			problems = append(problems, fmt.Sprintf("pair %d names %s as both the composite and the consumer type, which must differ", i+1, names.Composite))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// generatesMoreThanTypes tells whether any of the optional features adding
+// declarations besides the variant types and their methods is enabled.
+func (cfg Config) generatesMoreThanTypes() bool {
+	return cfg.EmitAssertions || cfg.Constructors || cfg.ConcreteConstructors || cfg.ValidatingConstructors ||
+		cfg.Sealed || cfg.GenerateMatch || cfg.GenerateBase || cfg.GenerateEqual || cfg.GenerateCopy ||
+		cfg.GenerateHash || cfg.GenerateString || cfg.GenerateJSON || cfg.GenerateFold || cfg.GenerateWalk ||
+		cfg.GenerateWalkParent || cfg.GenerateMap || cfg.GenerateSeq || cfg.GenerateKind || cfg.GenerateTag
+}
+
+// Generate writes the variant types for the configured composite/consumer
+// pairs to out.
 func (cfg Config) Generate(out io.Writer) error {
-	gen := &generator{Config: cfg}
-	return gen.run(out)
+	return cfg.GenerateContext(context.Background(), out)
+}
+
+// GenerateContext is like Generate, but gives up once the context is done,
+// returning its error. That's checked between parsing the package, generating
+// each variant and feature, and printing the code, so nothing gets written to
+// out then.
+func (cfg Config) GenerateContext(ctx context.Context, out io.Writer) error {
+	src, err := cfg.generateBytes(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(src)
+	return err
+}
+
+// GenerateBytes returns the formatted source of the variant types for the
+// configured composite/consumer pairs, including the generated code header.
+func (cfg Config) GenerateBytes() ([]byte, error) {
+	return cfg.generateBytes(context.Background())
+}
+
+func (cfg Config) generateBytes(ctx context.Context) ([]byte, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	gen := &generator{Config: cfg, ctx: ctx}
+	return gen.run()
+}
+
+// IsUpToDate tells whether existing is what GenerateBytes would produce now,
+// eg. for checking that a committed output file is not stale. Line endings
+// don't count, since checkouts might convert them.
+func (cfg Config) IsUpToDate(existing []byte) (bool, error) {
+	// NOTE: A dry run generates no code to compare against.
+	cfg.DryRun = false
+
+	src, err := cfg.GenerateBytes()
+	if err != nil {
+		return false, err
+	}
+
+	existing = bytes.ReplaceAll(existing, []byte("\r\n"), []byte("\n"))
+	return bytes.Equal(existing, src), nil
+}
+
+// Variants lists the names of the variant types that would be generated for
+// the composite/consumer pair named by TypeNames, in order, without generating
+// any code. Pairs are ignored.
+func (cfg Config) Variants() ([]string, error) {
+	gen, methods, err := cfg.consumerMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(methods))
+	for i, method := range methods {
+		names[i] = gen.variantName(method)
+	}
+	return names, nil
+}
+
+// MissingMethods lists the methods of the consumer named by TypeNames that the
+// type named implTypeName doesn't declare, in order. It's meant for checking
+// that a hand-written consumer implementation handles all the variants.
+//
+// NOTE: Only the methods declared in the source package on the type (or a
+// pointer to it) count. Promoted methods of embedded fields don't, and the
+// signatures are not compared.
+func (cfg Config) MissingMethods(implTypeName string) ([]string, error) {
+	gen, methods, err := cfg.consumerMethods()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = typeSpecNamed(gen.pkg, implTypeName)
+	if err != nil {
+		return nil, fmt.Errorf("can't retrieve consumer implementation type %s spec: %w", implTypeName, err)
+	}
+
+	declared := make(map[string]bool)
+	for _, f := range sortedFiles(gen.pkg) {
+		for _, decl := range f.Decls {
+			fun, ok := decl.(*ast.FuncDecl)
+			if ok && fun.Recv != nil && receiverTypeName(fun.Recv.List[0].Type) == implTypeName {
+				declared[fun.Name.Name] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, method := range methods {
+		if name := method.Names[0].Name; !declared[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing, nil
+}
+
+// consumerMethods finds the methods of the consumer named by TypeNames,
+// without generating any code.
+func (cfg Config) consumerMethods() (*generator, []*ast.Field, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gen := &generator{Config: cfg, fset: token.NewFileSet()}
+	err = gen.parsePackage()
+	if err != nil {
+		return nil, nil, err
+	}
+	err = gen.parseTypes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't parse the composite/consumer type pair: %w", err)
+	}
+
+	methods, err := gen.interfaceMethods(gen.consumer, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = gen.checkDuplicateVariants(methods)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gen, methods, nil
+}
+
+// GenerateFromPackage is like GenerateBytes, but works with a package that's
+// already parsed, instead of parsing Directory. The package should be parsed
+// with parser.ParseComments, for irgen directives to be found, and without
+// parser.SkipObjectResolution, since irgen looks names up in the file scopes.
+// Files is ignored. When the config leaves them empty, the package name and the
+// directory are taken from pkg.
+func (cfg Config) GenerateFromPackage(pkg *ast.Package, fset *token.FileSet) ([]byte, error) {
+	if cfg.PackageName == "" {
+		cfg.PackageName = pkg.Name
+	}
+	if cfg.Directory == "" {
+		cfg.Directory = packageDir(pkg, fset)
+	}
+
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if pkg.Name != cfg.PackageName {
+		return nil, fmt.Errorf("package %s given, but the config names package %s", pkg.Name, cfg.PackageName)
+	}
+
+	gen := &generator{Config: cfg, fset: fset, pkg: pkg}
+	return gen.run()
+}
+
+// Generate writes the variant types for a composite/consumer pair of an
+// already parsed package to w, with all the other options left at their
+// defaults.
+func Generate(pkg *ast.Package, fset *token.FileSet, names TypeNames, w io.Writer) error {
+	src, err := Config{TypeNames: names}.GenerateFromPackage(pkg, fset)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(src)
+	return err
+}
+
+// packageDir is the directory the files of a parsed package are in, or "" when
+// it has none.
+func packageDir(pkg *ast.Package, fset *token.FileSet) string {
+	files := sortedFiles(pkg)
+	if len(files) == 0 {
+		return ""
+	}
+	return filepath.Dir(fset.Position(files[0].Pos()).Filename)
 }
 
 type generator struct {
 	Config
 
+	// The context the generation can be canceled through. It's never done
+	// when nil.
+	ctx context.Context
+
 	fset                *token.FileSet
+	pkg                 *ast.Package
 	composite, consumer *ast.TypeSpec
 	file                *ast.File
+
+	// The interface type of the composite, which is declared by another type
+	// spec when the composite is an alias.
+	compositeIface *ast.InterfaceType
+
+	// The destructuring method of the composite and the (flattened) methods
+	// of the consumer, each of which describes a variant.
+	destructuring *ast.Field
+	variants      []*ast.Field
+
+	// The name of the receiver in the methods generated for the variants.
+	receiver string
+
+	// The consumer method parameters passed through the destructuring
+	// method instead of being stored in the variants.
+	passthrough []string
+
+	// The name of the marker method sealing the composite, when it's sealed.
+	sealMethod string
+
+	// The parsed MethodBodyTemplate, once it's needed.
+	bodyTemplate *template.Template
+
+	// The first declarations of groups to be set apart in the output.
+	groupStarts map[ast.Decl]bool
+
+	// The positions of the consumer methods the variant types come from,
+	// when line directives are added.
+	sourceLines map[*ast.TypeSpec]token.Position
+
+	// The package declaring the consumer and the import it's referred to
+	// by, when it's not the source package.
+	consumerPkg    *ast.Package
+	consumerImport importSpec
+
+	// The consumer type as declared in the source package, when it's an alias
+	// of an interface declared elsewhere.
+	consumerAlias *ast.TypeSpec
+
+	// Imports needed by the generated code, keyed by path.
+	imports map[string]importSpec
+
+	// The lines of the dry run summary, one per pair.
+	summary []string
 }
 
-func (gen *generator) run(out io.Writer) error {
-	gen.fset = token.NewFileSet()
+func (gen *generator) run() ([]byte, error) {
+	var err error
+	if gen.pkg == nil {
+		gen.fset = token.NewFileSet()
+		err = gen.parsePackage()
+	} else {
+		err = gen.checkDirectives()
+	}
+	if err != nil {
+		return nil, err
+	}
+	gen.logf("parsed package %s (%d files) in %s", gen.pkg.Name, len(gen.pkg.Files), gen.Directory)
 
-	err := gen.parseTypes()
+	err = gen.canceled()
 	if err != nil {
-		return errors.Wrap(err, "can't parse the composite/consumer type pair")
+		return nil, err
 	}
 
 	err = gen.generateAST()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if gen.PostProcess != nil {
+		err = gen.PostProcess(gen.file)
+		if err != nil {
+			return nil, fmt.Errorf("can't post-process the generated file: %w", err)
+		}
+	}
+
+	if gen.DryRun {
+		// NOTE: The build tags are otherwise only checked when the
+		// constraint gets rendered.
+		if len(gen.BuildTags) > 0 {
+			_, err := buildConstraint(gen.BuildTags)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, gen.summarize()
+	}
+
+	err = gen.canceled()
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := gen.dumpAST()
+	if err != nil {
+		return nil, err
+	}
+
+	if gen.Verify {
+		gen.logf("verifying the generated code")
+		err := gen.verify(src)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return gen.dumpAST(out)
+	return src, nil
 }
 
-func (gen *generator) parseTypes() (err error) {
-	pkgs, err := parser.ParseDir(gen.fset, gen.Directory, nil, 0)
+// parsePackage parses the source package. The comments are kept, since doc
+// comments get copied to the output and irgen directives are read from them.
+func (gen *generator) parsePackage() error {
+	if len(gen.Files) > 0 {
+		err := gen.parseFiles()
+		if err != nil {
+			return err
+		}
+		return gen.checkDirectives()
+	}
+
+	pkgs, err := parser.ParseDir(gen.fset, gen.Directory, nil, parser.ParseComments)
 	if err != nil {
-		return errors.Errorf("can't parse package %s from dir %q: %s", gen.PackageName, gen.Directory, err)
+		return fmt.Errorf("can't parse package %s from dir %q: %w", gen.PackageName, gen.Directory, err)
 	}
 
 	pkg, ok := pkgs[gen.PackageName]
 	if !ok {
-		return errors.Errorf("package %s not in directory %q", gen.PackageName, gen.Directory)
+		return fmt.Errorf("package %s not in directory %q", gen.PackageName, gen.Directory)
 	}
+	gen.pkg = pkg
+
+	return gen.checkDirectives()
+}
+
+// parseFiles parses only the configured files of the source package.
+func (gen *generator) parseFiles() error {
+	pkg := &ast.Package{Name: gen.PackageName, Files: make(map[string]*ast.File)}
+
+	for _, name := range gen.Files {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(gen.Directory, name)
+		}
+
+		f, err := parser.ParseFile(gen.fset, name, nil, parser.ParseComments)
+		if err != nil {
+			return fmt.Errorf("can't parse file %q of package %s: %w", name, gen.PackageName, err)
+		}
+		if f.Name.Name != gen.PackageName {
+			return gen.errorAt(f.Name, "file %q belongs to package %s, not %s", name, f.Name.Name, gen.PackageName)
+		}
+		pkg.Files[name] = f
+	}
+
+	gen.pkg = pkg
+	return nil
+}
+
+// parseTypes finds the composite and consumer types named by gen.TypeNames.
+func (gen *generator) parseTypes() (err error) {
+	pkg := gen.pkg
 
 	gen.composite, err = typeSpecNamed(pkg, gen.TypeNames.Composite)
 	if err != nil {
-		return errors.Wrapf(err, "can't retrieve composite type %s spec", gen.TypeNames.Composite)
+		return fmt.Errorf("can't retrieve composite type %s spec: %w", gen.TypeNames.Composite, err)
 	}
 
-	switch gen.composite.Type.(type) {
-	case *ast.InterfaceType:
-	default:
-		return errors.Errorf("composite type %s is not an interface", gen.TypeNames.Composite)
+	gen.compositeIface, err = gen.compositeInterface()
+	if err != nil {
+		return err
 	}
 
-	gen.consumer, err = typeSpecNamed(pkg, gen.TypeNames.Consumer)
+	gen.consumerPkg, gen.consumerImport, gen.consumerAlias = nil, importSpec{}, nil
+	if strings.Contains(gen.TypeNames.Consumer, ".") {
+		gen.consumer, err = gen.importedConsumer(gen.composite, gen.TypeNames.Consumer)
+	} else {
+		gen.consumer, err = typeSpecNamed(pkg, gen.TypeNames.Consumer)
+		if err == nil {
+			gen.consumer, err = gen.resolveConsumerAlias(gen.consumer)
+		}
+	}
 	if err != nil {
-		return errors.Wrapf(err, "can't retrieve consumer type %s spec", gen.TypeNames.Consumer)
+		return fmt.Errorf("can't retrieve consumer type %s spec: %w", gen.TypeNames.Consumer, err)
 	}
 
 	switch gen.consumer.Type.(type) {
 	case *ast.InterfaceType:
 	default:
-		return errors.Errorf("consumer type %s is not an interface", gen.TypeNames.Consumer)
+		return gen.errorAt(gen.consumer, "consumer type %s is %w", gen.TypeNames.Consumer, ErrNotInterface)
+	}
+
+	gen.passthrough, err = gen.passthroughParams()
+	if err != nil {
+		return err
+	}
+	if len(gen.passthrough) > 0 && (gen.GenerateMatch || gen.GenerateFold) {
+		return gen.errorAt(gen.consumer, "match and fold functions can't be generated with passthrough parameters")
+	}
+
+	gen.logf("found composite %s at %s and consumer %s at %s",
+		gen.TypeNames.Composite, gen.fset.Position(gen.composite.Pos()),
+		gen.TypeNames.Consumer, gen.fset.Position(gen.consumer.Pos()))
+
+	return gen.checkTypeParams()
+}
+
+// compositeInterface finds the interface type of the composite. When the
+// composite is an alias (or a defined type) naming an interface declared in
+// the same package, one level of that is resolved.
+func (gen *generator) compositeInterface() (*ast.InterfaceType, error) {
+	typ := gen.composite.Type
+
+	if ident, ok := typ.(*ast.Ident); ok && gen.composite.TypeParams == nil {
+		target, err := typeSpecNamed(gen.pkg, ident.Name)
+		if err == nil && target.TypeParams == nil {
+			typ = target.Type
+		}
+	}
+
+	iface, ok := typ.(*ast.InterfaceType)
+	if !ok {
+		return nil, gen.errorAt(gen.composite, "composite type %s is %w", gen.TypeNames.Composite, ErrNotInterface)
+	}
+	return iface, nil
+}
+
+func (gen *generator) generateAST() error {
+	var decls []ast.Decl
+
+	for _, names := range gen.pairs() {
+		gen.TypeNames = names
+
+		err := gen.canceled()
+		if err != nil {
+			return err
+		}
+
+		err = gen.parseTypes()
+		if err != nil {
+			return fmt.Errorf("can't parse the composite/consumer type pair: %w", err)
+		}
+
+		pairDecls, err := gen.generatePair()
+		if err != nil {
+			return err
+		}
+		decls = append(decls, pairDecls...)
+	}
+
+	if gen.generatesElsewhere() {
+		err := gen.qualifySourceNames(decls)
+		if err != nil {
+			return err
+		}
+	}
+
+	// NOTE: The imports go last, since generating the other declarations
+	// might add to them.
+	imports, err := gen.importDecl(decls)
+	if err != nil {
+		return err
+	}
+	if imports != nil {
+		decls = append([]ast.Decl{imports}, decls...)
+	}
+
+	gen.file = &ast.File{
+		Name:  &ast.Ident{Name: gen.outputPackage()},
+		Decls: decls,
+	}
+	return nil
+}
+
+// generatePair generates all the declarations for the current
+// composite/consumer pair.
+func (gen *generator) generatePair() ([]ast.Decl, error) {
+	typs, funs, err := gen.generateVariantTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: Without variants, the optional features would only produce empty
+	// declarations (like var ()) and helpers nothing calls.
+	if len(typs) == 0 {
+		gen.logf("consumer %s has no methods, so %s has no variants", gen.TypeNames.Consumer, gen.TypeNames.Composite)
+		return nil, nil
+	}
+
+	if gen.MethodsOnly {
+		err := gen.checkHandWrittenVariants(typs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Each variant type is followed by its method, so that the two can be
+	// read together.
+	decls := make([]ast.Decl, 0, 2*len(typs))
+	for i, typ := range typs {
+		// NOTE: The printer would put the doc comment of a lone type spec
+		// after the type keyword, so it's moved to the declaration.
+		doc := typ.Doc
+		typ.Doc = nil
+
+		if !gen.MethodsOnly {
+			decls = append(decls, &ast.GenDecl{
+				Doc:   doc,
+				Tok:   token.TYPE,
+				Specs: []ast.Spec{typ},
+			})
+		}
+		if !gen.TypesOnly {
+			decls = append(decls, funs[i])
+		}
+	}
+	if gen.EmitVariantManifest {
+		first := decls[0].(*ast.GenDecl)
+		first.Doc = variantManifest(first.Doc, typs)
+	}
+
+	// Each optional feature adds a group of declarations.
+	features := []struct {
+		enabled  bool
+		generate func() ([]ast.Decl, error)
+	}{
+		{gen.EmitAssertions, func() ([]ast.Decl, error) { return gen.generateAssertions(typs) }},
+		{gen.Constructors, func() ([]ast.Decl, error) { return gen.generateConstructors(typs, false) }},
+		{gen.ConcreteConstructors, func() ([]ast.Decl, error) { return gen.generateConstructors(typs, true) }},
+		{gen.ValidatingConstructors, func() ([]ast.Decl, error) { return gen.generateValidatingConstructors(typs) }},
+		{gen.Sealed, func() ([]ast.Decl, error) { return gen.generateSeal(typs) }},
+		{gen.GenerateMatch, func() ([]ast.Decl, error) { return gen.generateMatch(), nil }},
+		{gen.GenerateBase, func() ([]ast.Decl, error) { return gen.generateBase(), nil }},
+		{gen.GenerateEqual, func() ([]ast.Decl, error) { return gen.generateEqual(typs) }},
+		{gen.GenerateCopy, func() ([]ast.Decl, error) { return gen.generateCopy(typs) }},
+		{gen.GenerateHash, func() ([]ast.Decl, error) { return gen.generateHash(typs) }},
+		{gen.GenerateString, func() ([]ast.Decl, error) { return gen.generateString(typs) }},
+		{gen.GenerateJSON, func() ([]ast.Decl, error) { return gen.generateJSON(typs) }},
+		{gen.GenerateFold, gen.generateFold},
+		{gen.GenerateWalk, func() ([]ast.Decl, error) { return gen.generateWalk(typs) }},
+		{gen.GenerateWalkParent, func() ([]ast.Decl, error) { return gen.generateWalkParent(typs) }},
+		{gen.GenerateMap, func() ([]ast.Decl, error) { return gen.generateMap(typs) }},
+		{gen.GenerateSeq, func() ([]ast.Decl, error) { return gen.generateSeq(typs) }},
+		{gen.GenerateKind, func() ([]ast.Decl, error) { return gen.generateKind(typs) }},
+		{gen.GenerateTag, func() ([]ast.Decl, error) { return gen.generateTag(typs) }},
+	}
+
+	for _, feature := range features {
+		if !feature.enabled {
+			continue
+		}
+
+		err := gen.canceled()
+		if err != nil {
+			return nil, err
+		}
+
+		group, err := feature.generate()
+		if err != nil {
+			return nil, err
+		}
+		decls = gen.appendGroup(decls, group)
+	}
+
+	return decls, nil
+}
+
+// canceled returns the error of the context the generation runs in, once it's
+// done.
+func (gen *generator) canceled() error {
+	if gen.ctx == nil {
+		return nil
+	}
+	return gen.ctx.Err()
+}
+
+// appendGroup appends a group of related declarations, which is set apart
+// from the preceding ones by a blank line in the output.
+func (gen *generator) appendGroup(decls, group []ast.Decl) []ast.Decl {
+	if len(group) == 0 {
+		return decls
+	}
+
+	if gen.groupStarts == nil {
+		gen.groupStarts = make(map[ast.Decl]bool)
+	}
+	gen.groupStarts[group[0]] = true
+
+	return append(decls, group...)
+}
+
+func (gen *generator) dumpAST() ([]byte, error) {
+	var buf bytes.Buffer
+
+	// NOTE: Each part of the header is set apart by a blank line, so that
+	// none of them ends up as the package doc comment.
+	if gen.LicenseHeader != "" {
+		buf.WriteString(licenseComment(gen.LicenseHeader) + "\n")
+	}
+	if gen.Header != "" {
+		buf.WriteString(headerComment(gen.Header) + "\n")
+	}
+	if !gen.OmitHeader {
+		buf.WriteString("// Code generated by irgen; DO NOT EDIT.\n\n")
+	}
+
+	if len(gen.BuildTags) > 0 {
+		lines, err := buildConstraint(gen.BuildTags)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(lines + "\n")
+	}
+
+	fmt.Fprintf(&buf, "package %s\n", gen.file.Name.Name)
+
+	// NOTE: The generated nodes have no positions the printer could use to
+	// place comments. So each declaration is printed on its own, after its
+	// doc comment is written out by hand. The result is then formatted as a
+	// whole, to get the alignment gofmt would produce.
+	//
+	// Declarations are separated by blank lines, except for runs of the same
+	// kind within a group -- unless those are functions spanning several
+	// lines.
+	var (
+		declBuf       bytes.Buffer
+		prev          = token.ILLEGAL
+		prevMultiline bool
+		nolint        string
+	)
+	if len(gen.LintSuppressions) > 0 {
+		nolint = "//nolint:" + strings.Join(gen.LintSuppressions, ",") + "\n"
+	}
+	// NOTE: A declaration takes a couple hundred bytes, give or take. Growing
+	// the buffer upfront saves copying it over and over for big consumers.
+	buf.Grow(256 * len(gen.file.Decls))
+	for _, decl := range gen.file.Decls {
+
+		declBuf.Reset()
+		err := format.Node(&declBuf, gen.fset, withoutDoc(decl))
+		if err != nil {
+			return nil, err
+		}
+
+		printed := declBuf.Bytes()
+		if gen.LineDirectives {
+			printed = gen.withLineDirectives(decl, printed)
+		}
+
+		tok, doc := declToken(decl), declDoc(decl)
+		multiline := tok == token.FUNC && bytes.Contains(printed, []byte("\n"))
+		if tok != prev || doc != nil || multiline || prevMultiline || gen.groupStarts[decl] {
+			buf.WriteString("\n")
+		}
+		prev, prevMultiline = tok, multiline
+
+		if doc != nil {
+			for _, comment := range doc.List {
+				buf.WriteString(dedentBlockComment(comment.Text))
+				buf.WriteString("\n")
+			}
+		}
+		if nolint != "" && tok != token.IMPORT {
+			buf.WriteString(nolint)
+		}
+
+		buf.Write(printed)
+		buf.WriteString("\n")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("can't format the generated code: %w", err)
+	}
+
+	return gen.resolveLineResets(src), nil
+}
+
+// isLinterName tells whether a //nolint directive can name the linter, like
+// gocritic or revive.
+func isLinterName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// headerComment turns the header text into line comments, leaving the lines
+// that already are comments alone.
+func headerComment(header string) string {
+	lines := strings.Split(strings.TrimRight(header, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "//"):
+		case strings.TrimSpace(line) == "":
+			lines[i] = "//"
+		default:
+			lines[i] = "// " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// buildConstraint renders the build constraint lines requiring all of the
+// tags, both in the //go:build and the legacy // +build form.
+func buildConstraint(tags []string) (string, error) {
+	terms := make([]string, len(tags))
+	for i, tag := range tags {
+		terms[i] = "(" + tag + ")"
+	}
+
+	expr, err := constraint.Parse("//go:build " + strings.Join(terms, " && "))
+	if err != nil {
+		return "", fmt.Errorf("invalid build tags %q: %w", tags, err)
+	}
+
+	lines := []string{"//go:build " + expr.String()}
+	plusBuild, err := constraint.PlusBuildLines(expr)
+	if err != nil {
+		return "", fmt.Errorf("can't express build tags %q as a // +build line: %w", tags, err)
+	}
+	lines = append(lines, plusBuild...)
+
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+func declToken(decl ast.Decl) token.Token {
+	switch decl := decl.(type) {
+	case *ast.GenDecl:
+		return decl.Tok
+	case *ast.FuncDecl:
+		return token.FUNC
+	default:
+		return token.ILLEGAL
 	}
-
-	return nil
 }
 
-func (gen *generator) generateAST() error {
-	typs, funs, err := gen.generateVariantTypes()
-	if err != nil {
-		return err
+// dedentBlockComment strips the indentation a /* */ comment had in the source
+// from its lines, keeping only the relative indentation of its text. Otherwise
+// the comment would keep moving under gofmt, now that it starts a top-level
+// declaration. Line comments are returned as is.
+func dedentBlockComment(text string) string {
+	if !strings.HasPrefix(text, "/*") {
+		return text
 	}
 
-	var decls []ast.Decl
-	for _, typ := range typs {
-		decls = append(decls, &ast.GenDecl{
-			Tok:   token.TYPE,
-			Specs: []ast.Spec{typ},
-		})
+	lines := strings.Split(text, "\n")
+	last := len(lines) - 1
+	if last == 0 {
+		return text
 	}
-	for _, fun := range funs {
-		decls = append(decls, fun)
+	lines[last] = strings.TrimLeft(lines[last], " \t")
+
+	indent := ""
+	first := true
+	for _, line := range lines[1:last] {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		switch {
+		case first:
+			indent, first = lead, false
+		default:
+			for !strings.HasPrefix(lead, indent) {
+				indent = indent[:len(indent)-1]
+			}
+		}
 	}
+	for i := 1; i < last; i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+	return strings.Join(lines, "\n")
+}
 
-	gen.file = &ast.File{
-		Name:  &ast.Ident{Name: gen.PackageName},
-		Decls: decls,
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch decl := decl.(type) {
+	case *ast.GenDecl:
+		return decl.Doc
+	case *ast.FuncDecl:
+		return decl.Doc
+	default:
+		return nil
 	}
-	return nil
 }
 
-func (gen *generator) dumpAST(out io.Writer) error {
-	_, err := io.WriteString(out, "// Code generated by irgen; DO NOT EDIT.\n\n")
-	if err != nil {
-		return err
+// withoutDoc returns a shallow copy of the declaration with the doc comment
+// removed.
+func withoutDoc(decl ast.Decl) ast.Decl {
+	switch decl := decl.(type) {
+	case *ast.GenDecl:
+		copied := *decl
+		copied.Doc = nil
+		return &copied
+	case *ast.FuncDecl:
+		copied := *decl
+		copied.Doc = nil
+		return &copied
+	default:
+		return decl
+	}
+}
+
+// sortedFiles lists the files of a package ordered by name. Ranging over the
+// map directly would make the order differ between runs -- and with it the
+// output or the errors reported.
+func sortedFiles(pkg *ast.Package) []*ast.File {
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	return format.Node(out, gen.fset, gen.file)
+	files := make([]*ast.File, len(names))
+	for i, name := range names {
+		files[i] = pkg.Files[name]
+	}
+	return files
 }
 
 func typeSpecNamed(pkg *ast.Package, name string) (*ast.TypeSpec, error) {
@@ -143,7 +1261,7 @@ func typeSpecNamed(pkg *ast.Package, name string) (*ast.TypeSpec, error) {
 func typeSpecsNamed(pkg *ast.Package, name string) []*ast.TypeSpec {
 	var specs []*ast.TypeSpec
 
-	for _, f := range pkg.Files {
+	for _, f := range sortedFiles(pkg) {
 
 		for _, decl := range f.Decls {
 
@@ -168,67 +1286,353 @@ func typeSpecsNamed(pkg *ast.Package, name string) []*ast.TypeSpec {
 }
 
 func (gen *generator) generateVariantTypes() ([]*ast.TypeSpec, []*ast.FuncDecl, error) {
-	var (
-		typs []*ast.TypeSpec
-		funs []*ast.FuncDecl
-	)
-	compIface := gen.composite.Type.(*ast.InterfaceType)
-	if compIface.Methods.NumFields() != 1 {
-		return nil, nil, errors.Errorf(
-			"the composite type should have 1 method (has %d)",
-			compIface.Methods.NumFields())
+	compMethods := gen.compositeIface.Methods.List
+	if gen.Sealed {
+		if gen.generatesElsewhere() {
+			return nil, nil, gen.errorAt(gen.composite,
+				"the composite type %s can't be sealed from package %s",
+				gen.TypeNames.Composite, gen.outputPackage())
+		}
+		compMethods = gen.findSealMethod(compMethods)
+	}
+
+	compMethod, err := gen.findDestructuringMethod(compMethods)
+	if err != nil && gen.seemsSwapped() {
+		return nil, nil, fmt.Errorf("%w (are the arguments swapped? %s looks like the composite and %s like the consumer)",
+			err, gen.TypeNames.Consumer, gen.TypeNames.Composite)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if gen.MethodName != "" && gen.MethodName != compMethod.Names[0].Name {
+		return nil, nil, gen.errorAt(compMethod,
+			"composite method is named %s, not %s (the variants would not implement %s)",
+			compMethod.Names[0].Name, gen.MethodName, gen.TypeNames.Composite)
+	}
+
+	err = gen.collectImports(fileContaining(gen.pkg, gen.compositeIface), compMethod.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	methods, err := gen.interfaceMethods(gen.consumer, nil)
+	if err != nil {
+		return nil, nil, err
 	}
-	compMethod := compIface.Methods.List[0]
-	err := gen.checkDestructuringMethod(compMethod)
+
+	err = gen.checkDuplicateVariants(methods)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	for _, method := range gen.consumer.Type.(*ast.InterfaceType).Methods.List {
+	// NOTE: A bad consumer method doesn't stop the others from being checked,
+	// so that all the problems get reported together.
+	var errs []error
+	fieldsMethods := make([]*ast.Field, 0, len(methods))
+	fieldTags := make([]map[string]string, 0, len(methods))
+	for i, method := range methods {
+		method, fieldsMethod, tags, err := gen.prepareConsumerMethod(compMethod, method)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		methods[i] = method
+		fieldsMethods = append(fieldsMethods, fieldsMethod)
+		fieldTags = append(fieldTags, tags)
+	}
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	// NOTE: The receiver can only be picked once all the imports and fields
+	// are known.
+	err = gen.chooseReceiverName(fieldsMethods)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		err := checkConsumerMethod(method)
+	typs := make([]*ast.TypeSpec, 0, len(fieldsMethods))
+	funs := make([]*ast.FuncDecl, 0, len(fieldsMethods))
+	for i, method := range fieldsMethods {
+		err := gen.canceled()
 		if err != nil {
 			return nil, nil, err
 		}
 
-		typ, fun := gen.generateVariantType(compMethod, method)
+		gen.logf("generating variant %s of %s", gen.variantName(method), gen.TypeNames.Composite)
+		typ, fun := gen.generateVariantType(compMethod, method, fieldTags[i])
+		if gen.MethodBodyTemplate != "" {
+			fun, err = gen.withTemplateBody(typ, fun)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if gen.LineDirectives {
+			if gen.sourceLines == nil {
+				gen.sourceLines = make(map[*ast.TypeSpec]token.Position)
+			}
+			gen.sourceLines[typ] = gen.fset.Position(method.Pos())
+		}
 		typs = append(typs, typ)
 		funs = append(funs, fun)
 	}
 
+	gen.destructuring, gen.variants = compMethod, methods
+	gen.summary = append(gen.summary, gen.summaryLine(typs))
+
 	return typs, funs, nil
 }
 
-func checkConsumerMethod(method *ast.Field) error {
+// prepareConsumerMethod checks a consumer method and returns it with the types
+// qualified for the output, along with the method the variant fields are made
+// from and the field tags.
+func (gen *generator) prepareConsumerMethod(compMethod, method *ast.Field) (*ast.Field, *ast.Field, map[string]string, error) {
+	// NOTE: The imports are collected first, so that the types in the
+	// method are known to mean the same as in the composite method.
+	err := gen.collectImports(fileContaining(gen.consumerPackage(), method), method.Type)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	method, err = gen.qualifyConsumerMethod(method)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// NOTE: The passthrough parameters and the accumulator are not variant
+	// fields, but they're still part of the consumer methods other features
+	// implement.
+	fieldsMethod, err := gen.withoutPassthrough(compMethod, method)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fieldsMethod, err = gen.withoutAccumulator(fieldsMethod)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if !gen.StrictNames {
+		fieldsMethod, err = gen.withPositionalNames(fieldsMethod)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		// NOTE: The features implementing the consumer (like match and
+		// fold) refer to all the arguments by name too.
+		method, err = gen.withPositionalNames(method)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	err = gen.checkConsumerMethod(compMethod, fieldsMethod)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tags, err := gen.fieldTags(fieldsMethod)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// NOTE: The tags and the renames both refer to the parameters, so the
+	// fields are only renamed once the tags are known.
+	renames, err := gen.fieldRenames(fieldsMethod)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fieldsMethod, tags = withRenamedFields(fieldsMethod, renames, tags)
+
+	return method, fieldsMethod, tags, nil
+}
+
+// interfaceMethods lists the methods of an interface type, with the methods of
+// embedded interfaces flattened in at the point of embedding. The embedding
+// chain leading to spec is used to detect cycles.
+func (gen *generator) interfaceMethods(spec *ast.TypeSpec, embedding []string) ([]*ast.Field, error) {
+	for _, name := range embedding {
+		if name == spec.Name.Name {
+			return nil, gen.errorAt(spec, "interface %s embeds itself", name)
+		}
+	}
+	embedding = append(embedding, spec.Name.Name)
+
+	iface, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil, gen.errorAt(spec, "type %s is %w", spec.Name.Name, ErrNotInterface)
+	}
+
+	var methods []*ast.Field
+	for _, field := range iface.Methods.List {
+
+		if isTypeElement(field.Type) {
+			return nil, gen.errorAt(field,
+				"interface %s has the type element %s, which makes it a constraint (the consumer has to be an interface with only methods)",
+				spec.Name.Name, types.ExprString(field.Type))
+		}
+
+		switch typ := field.Type.(type) {
+		case *ast.FuncType:
+			methods = append(methods, field)
+
+		case *ast.Ident:
+			embedded, err := typeSpecNamed(gen.consumerPackage(), typ.Name)
+			if err != nil {
+				return nil, fmt.Errorf("can't resolve interface %s embedded in %s: %w", typ.Name, spec.Name.Name, err)
+			}
+
+			embeddedMethods, err := gen.interfaceMethods(embedded, embedding)
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, embeddedMethods...)
+
+		case *ast.SelectorExpr:
+			return nil, gen.errorAt(field,
+				"interface %s embeds %s from another package, which irgen can't resolve",
+				spec.Name.Name, types.ExprString(typ))
+
+		default:
+			return nil, gen.errorAt(field,
+				"interface %s embeds %s, which irgen can't resolve",
+				spec.Name.Name, types.ExprString(typ))
+		}
+	}
+
+	return methods, nil
+}
+
+// isTypeElement tells whether an embedded element of an interface is a type
+// element, like ~int | ~string, rather than a method or an embedded interface.
+// Predeclared types other than error count too, since they're not interfaces
+// with methods.
+func isTypeElement(typ ast.Expr) bool {
+	switch typ := typ.(type) {
+	case *ast.UnaryExpr:
+		return typ.Op == token.TILDE
+	case *ast.BinaryExpr:
+		return typ.Op == token.OR
+	case *ast.Ident:
+		obj := types.Universe.Lookup(typ.Name)
+		_, isType := obj.(*types.TypeName)
+		return isType && typ.Name != "error" && typ.Name != "any"
+	default:
+		return false
+	}
+}
+
+// checkDuplicateVariants makes sure no two consumer methods have the same name,
+// which would make for two variant types with the same name.
+func (gen *generator) checkDuplicateVariants(methods []*ast.Field) error {
+	seen := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		name := method.Names[0].Name
+		if seen[name] {
+			return gen.errorAt(method,
+				"consumer method %s is declared more than once (the variant names have to be unique)",
+				name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// withPositionalNames returns a copy of a consumer method with the unnamed and
+// blank arguments named after their position, eg. Arg0, so that they can become
+// variant fields.
+func (gen *generator) withPositionalNames(method *ast.Field) (*ast.Field, error) {
+	typ := method.Type.(*ast.FuncType)
+
+	named := make(map[string]bool)
+	for _, group := range typ.Params.List {
+		for _, name := range group.Names {
+			named[name.Name] = true
+		}
+	}
+
+	fields := copyFieldList(typ.Params)
+	renamed := false
+	i := 0
+	for _, group := range fields.List {
+		if len(group.Names) == 0 {
+			// NOTE: Go doesn't allow mixing named and unnamed arguments,
+			// so each unnamed group is a single argument.
+			group.Names = []*ast.Ident{{Name: "_"}}
+		}
+
+		for _, name := range group.Names {
+			if name.Name == "_" {
+				name.Name = fmt.Sprintf("Arg%d", i)
+				renamed = true
+				if named[name.Name] {
+					return nil, gen.errorAt(method,
+						"consumer method %s has an argument named %s, which its argument number %d would be named too",
+						method.Names[0].Name, name.Name, i+1)
+				}
+			}
+			i++
+		}
+	}
+	if !renamed {
+		return method, nil
+	}
+
+	return &ast.Field{
+		Doc:     method.Doc,
+		Names:   method.Names,
+		Type:    &ast.FuncType{TypeParams: typ.TypeParams, Params: fields, Results: typ.Results},
+		Comment: method.Comment,
+	}, nil
+}
+
+func (gen *generator) checkConsumerMethod(compositeMethod, method *ast.Field) error {
 	typ := method.Type.(*ast.FuncType)
 	for _, argGroup := range typ.Params.List {
 
 		if len(argGroup.Names) == 0 {
-			return errors.Errorf(
+			return gen.errorAt(argGroup,
 				"consumer method %s has unnamed arguments",
 				method.Names[0].Name)
 		}
 
+		// NOTE: Only the argument names become field names. The names of
+		// parameters within function-typed arguments don't matter.
 		for _, name := range argGroup.Names {
 
 			if !name.IsExported() {
-				return errors.Errorf(
+				return gen.errorAt(name,
 					"consumer method %s has argument names that can't be turned into exported field names",
 					method.Names[0].Name)
 			}
 		}
 	}
 
-	if typ.Results.NumFields() > 0 {
-		return errors.Errorf(
-			"consumer method %s has %d results (should have none)",
-			method.Names[0].Name, typ.Results.NumFields())
+	// The consumer methods are what the destructuring method forwards to, so
+	// their results have to be the ones the destructuring method returns.
+	compResults := compositeMethod.Type.(*ast.FuncType).Results
+	n, want := typ.Results.NumFields(), compResults.NumFields()
+	if n != want {
+		return gen.errorAt(method,
+			"consumer method %s has %d results (should have %d, like composite method %s)",
+			method.Names[0].Name, n, want, compositeMethod.Names[0].Name)
+	}
+
+	if n == 1 {
+		got := types.ExprString(typ.Results.List[0].Type)
+		want := types.ExprString(compResults.List[0].Type)
+		if got != want {
+			return gen.errorAt(typ.Results,
+				"consumer method %s returns %s (should return %s, like composite method %s)",
+				method.Names[0].Name, got, want, compositeMethod.Names[0].Name)
+		}
 	}
 
 	return nil
 }
 
-func (gen *generator) generateVariantType(compositeMethod, consumerMethod *ast.Field) (*ast.TypeSpec, *ast.FuncDecl) {
+func (gen *generator) generateVariantType(compositeMethod, consumerMethod *ast.Field, tags map[string]string) (*ast.TypeSpec, *ast.FuncDecl) {
 
 	// NOTE: As we build the AST here, we're making manual copies instead of
 	// reusing nodes from the original package sources. When this happens,
@@ -236,33 +1640,54 @@ func (gen *generator) generateVariantType(compositeMethod, consumerMethod *ast.F
 	// information in the nodes into account when deciding where to insert
 	// whitespace.
 
-	typName := consumerMethod.Names[0]
-	funName := compositeMethod.Names[0]
+	typName := &ast.Ident{Name: gen.variantName(consumerMethod)}
+	funName := &ast.Ident{Name: compositeMethod.Names[0].Name}
 
 	fields := consumerMethod.Type.(*ast.FuncType).Params.List
 
+	structFields := taggedFields(sliceFields(fields), tags)
+	if gen.ExpandGroups {
+		structFields = expandGroups(structFields)
+	}
 	shape := &ast.StructType{
-		Fields: &ast.FieldList{
-			List: fields,
-		},
+		Fields: &ast.FieldList{List: structFields},
 	}
 
 	typ := &ast.TypeSpec{
-		Name: typName,
-		Type: shape,
+		Doc:        copyCommentGroup(consumerMethod.Doc),
+		Name:       typName,
+		TypeParams: gen.typeParams(),
+		Type:       shape,
 	}
 
 	argName := &ast.Ident{Name: "consumer"}
-	funtyp := compositeMethod.Type.(*ast.FuncType)
-	funtyp.Params.List[0].Names = []*ast.Ident{argName}
+	// NOTE: The composite method is shared between all the variants, so each
+	// one gets its own copy of the signature to name the arguments in.
+	funtyp := copyFuncType(compositeMethod.Type.(*ast.FuncType))
+	param := 0
+	for _, field := range funtyp.Params.List {
+		names := make([]*ast.Ident, max(len(field.Names), 1))
+		for i := range names {
+			names[i] = &ast.Ident{Name: gen.destructuringParamName(param)}
+			param++
+		}
+		field.Names = names
+	}
 
-	recvName := &ast.Ident{Name: gen.composite.Name.Name}
+	recvName := &ast.Ident{Name: gen.receiverName()}
 
 	// NOTE: See the note at the top of this function.
 	consumerMethodName := &ast.Ident{Name: consumerMethod.Names[0].Name}
 	methodLookup := &ast.SelectorExpr{X: argName, Sel: consumerMethodName}
+	if gen.takesConsumerByPointer(compositeMethod) {
+		// NOTE: Methods can't be called through pointers to interfaces.
+		methodLookup.X = &ast.ParenExpr{X: &ast.StarExpr{X: argName}}
+	}
 
 	var args []ast.Expr
+	for _, name := range gen.passthrough {
+		args = append(args, &ast.Ident{Name: paramName(name)})
+	}
 	for _, field := range fields {
 
 		for _, name := range field.Names {
@@ -274,7 +1699,12 @@ func (gen *generator) generateVariantType(compositeMethod, consumerMethod *ast.F
 		}
 	}
 
+	if gen.AccumulatorType != "" {
+		args = append(args, &ast.Ident{Name: "acc"})
+	}
+
 	call := &ast.CallExpr{Fun: methodLookup, Args: args}
+	spreadVariadic(call, consumerMethod.Type.(*ast.FuncType).Params)
 
 	var body *ast.BlockStmt
 
@@ -288,7 +1718,10 @@ func (gen *generator) generateVariantType(compositeMethod, consumerMethod *ast.F
 		}
 	}
 
-	recvTyp := &ast.StarExpr{X: typName}
+	recvTyp := gen.instantiate(typName.Name)
+	if !gen.ValueReceiver {
+		recvTyp = &ast.StarExpr{X: recvTyp}
+	}
 
 	recv := &ast.FieldList{
 		List: []*ast.Field{
@@ -307,28 +1740,384 @@ func (gen *generator) generateVariantType(compositeMethod, consumerMethod *ast.F
 	return typ, fun
 }
 
+// logf reports what the generator is doing through Config.Logf, if it's set.
+func (gen *generator) logf(format string, args ...interface{}) {
+	if gen.Logf != nil {
+		gen.Logf(format, args...)
+	}
+}
+
+// errorAt builds an error about a node of the source package, starting with
+// its position, so that it's easy to find. Like with fmt.Errorf, the format can
+// wrap an error with %w.
+func (gen *generator) errorAt(node ast.Node, format string, args ...interface{}) error {
+	pos := gen.fset.Position(node.Pos())
+	if !pos.IsValid() {
+		// NOTE: Generated nodes don't have positions.
+		return fmt.Errorf(format, args...)
+	}
+	return fmt.Errorf("%s: "+format, append([]interface{}{pos}, args...)...)
+}
+
+// sliceFields turns a variadic parameter into a slice, so that the parameters
+// can become struct fields. The other fields are left alone.
+func sliceFields(fields []*ast.Field) []*ast.Field {
+	if len(fields) == 0 {
+		return fields
+	}
+
+	last := fields[len(fields)-1]
+	variadic, ok := last.Type.(*ast.Ellipsis)
+	if !ok {
+		return fields
+	}
+
+	sliced := append([]*ast.Field(nil), fields[:len(fields)-1]...)
+	return append(sliced, &ast.Field{
+		Names:   last.Names,
+		Type:    &ast.ArrayType{Elt: variadic.Elt},
+		Tag:     last.Tag,
+		Comment: last.Comment,
+	})
+}
+
+// expandGroups splits the fields declared together, like Left, Right Expr, so
+// that each one is declared on its own.
+func expandGroups(fields []*ast.Field) []*ast.Field {
+	expanded := make([]*ast.Field, 0, len(fields))
+	for _, field := range fields {
+		if len(field.Names) < 2 {
+			expanded = append(expanded, field)
+			continue
+		}
+		for _, name := range field.Names {
+			expanded = append(expanded, &ast.Field{
+				Names: []*ast.Ident{{Name: name.Name}},
+				Type:  field.Type,
+				Tag:   field.Tag,
+			})
+		}
+	}
+	return expanded
+}
+
+// spreadVariadic makes the call pass its last argument with ..., when it's
+// meant for the variadic parameter of the params.
+func spreadVariadic(call *ast.CallExpr, params *ast.FieldList) {
+	if params.NumFields() == 0 {
+		return
+	}
+
+	variadic, ok := params.List[len(params.List)-1].Type.(*ast.Ellipsis)
+	if ok {
+		// NOTE: The printer only needs a valid position to print the
+		// ellipsis, so the one from the source does.
+		call.Ellipsis = variadic.Ellipsis
+	}
+}
+
+// variantName is the name of the type generated for a consumer method.
+func (gen *generator) variantName(consumerMethod *ast.Field) string {
+	return gen.TypeNames.VariantPrefix + consumerMethod.Names[0].Name + gen.TypeNames.VariantSuffix
+}
+
+// receiverName is the name of the receiver in the methods generated for the
+// variants, as picked by chooseReceiverName.
+func (gen *generator) receiverName() string {
+	return gen.receiver
+}
+
+// chooseReceiverName picks the receiver name for the variants of the current
+// pair, given the consumer methods describing their fields. The one from the
+// config is taken when there is one, as long as it doesn't collide with
+// anything. Otherwise it's the first letter of the composite name, lowercased
+// -- which can't be a keyword -- with underscores appended while it collides.
+func (gen *generator) chooseReceiverName(methods []*ast.Field) error {
+	fields := make(map[string]bool)
+	for _, method := range methods {
+		for _, field := range method.Type.(*ast.FuncType).Params.List {
+			for _, name := range field.Names {
+				fields[name.Name] = true
+			}
+		}
+	}
+
+	collides := func(name string) bool {
+		return fields[name] || gen.usedInMethodBodies(name) || gen.declared(name) || gen.isTypeParam(name)
+	}
+
+	if gen.ReceiverName != "" {
+		if collides(gen.ReceiverName) {
+			return gen.errorAt(gen.composite,
+				"receiver name %s collides with a field, parameter, type or package the generated methods use",
+				gen.ReceiverName)
+		}
+		gen.receiver = gen.ReceiverName
+		return nil
+	}
+
+	first, _ := utf8.DecodeRuneInString(gen.composite.Name.Name)
+	name := string(unicode.ToLower(first))
+	for collides(name) {
+		name += "_"
+	}
+	gen.receiver = name
+	return nil
+}
+
+// usedInMethodBodies tells whether the methods generated for the variants might
+// refer to something else by the name -- their other parameters, local
+// variables or imported packages.
+func (gen *generator) usedInMethodBodies(name string) bool {
+	switch name {
+	case "consumer", "acc", "other", "that", "ok", "i", "key", "value", "thatValue", "h", "item", "entry", "sum",
+		"fmt", "strings", "maps", "fnv", "binary", "reflect", "json":
+		return true
+	}
+
+	for _, param := range gen.passthrough {
+		if paramName(param) == name {
+			return true
+		}
+	}
+
+	for _, imp := range gen.imports {
+		if imp.localName() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// copyCommentGroup copies a comment group without its positions, so that the
+// printer emits it right before whatever node it's attached to. The irgen
+// directives are left out.
+func copyCommentGroup(group *ast.CommentGroup) *ast.CommentGroup {
+	if group == nil {
+		return nil
+	}
+
+	copied := &ast.CommentGroup{}
+	for _, comment := range group.List {
+		if _, _, ok := directive(comment); ok {
+			continue
+		}
+		copied.List = append(copied.List, &ast.Comment{Text: comment.Text})
+	}
+	if len(copied.List) == 0 {
+		return nil
+	}
+	return copied
+}
+
+// copyFuncType copies a function signature down to the fields, so that the
+// copy can be modified without affecting the original. The types of the
+// parameters and results are still shared.
+//
+// The positions are kept, since format.Node relies on them to decide whether
+// a method fits on a single line.
+func copyFuncType(typ *ast.FuncType) *ast.FuncType {
+	return &ast.FuncType{
+		Func:    typ.Func,
+		Params:  copyFieldList(typ.Params),
+		Results: copyFieldList(typ.Results),
+	}
+}
+
+func copyFieldList(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+
+	copied := &ast.FieldList{
+		Opening: fields.Opening,
+		List:    make([]*ast.Field, 0, len(fields.List)),
+		Closing: fields.Closing,
+	}
+	for _, field := range fields.List {
+		var names []*ast.Ident
+		for _, name := range field.Names {
+			names = append(names, &ast.Ident{Name: name.Name})
+		}
+		copied.List = append(copied.List, &ast.Field{Names: names, Type: field.Type, Tag: field.Tag})
+	}
+	return copied
+}
+
+// findDestructuringMethod picks the composite method taking the consumer, which
+// is the one the variants implement. The other methods of the composite are
+// left for the user to implement.
+func (gen *generator) findDestructuringMethod(methods []*ast.Field) (*ast.Field, error) {
+	// NOTE: A lone method has to be the destructuring one, so it's checked
+	// thoroughly to tell what's wrong with it.
+	if len(methods) == 1 && isMethod(methods[0]) {
+		return methods[0], gen.checkDestructuringMethod(methods[0])
+	}
+
+	consumer := types.ExprString(gen.instantiate(gen.TypeNames.Consumer))
+	acc := gen.accumulatorParam()
+
+	var candidates []*ast.Field
+	for _, method := range methods {
+		if !isMethod(method) {
+			continue
+		}
+		params := method.Type.(*ast.FuncType).Params
+		if gen.takesConsumer(params) {
+			candidates = append(candidates, method)
+		}
+	}
+
+	args := "a single " + consumer + " argument"
+	if acc != "" {
+		args = consumer + " and " + acc + " arguments"
+	}
+	if len(gen.passthrough) > 0 {
+		args = "the " + strings.Join(gen.passthrough, ", ") + " passthrough arguments followed by " + strings.TrimPrefix(args, "a single ")
+	}
+
+	if len(candidates) > 1 && gen.MethodName != "" {
+		var named []*ast.Field
+		for _, method := range candidates {
+			if method.Names[0].Name == gen.MethodName {
+				named = append(named, method)
+			}
+		}
+		candidates = named
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, gen.errorAt(gen.composite,
+			"composite type %s has no method with %s: %w",
+			gen.TypeNames.Composite, args, ErrMethodCount)
+	case 1:
+		return candidates[0], gen.checkDestructuringMethod(candidates[0])
+	default:
+		return nil, gen.errorAt(candidates[1],
+			"composite type %s has more than one method with %s (%s and %s): %w",
+			gen.TypeNames.Composite, args, candidates[0].Names[0].Name, candidates[1].Names[0].Name, ErrMethodCount)
+	}
+}
+
+// seemsSwapped tells whether the composite and consumer type names look like
+// they were passed the other way around: the consumer has a lone method, taking
+// the composite first -- like a destructuring method would take the consumer.
+func (gen *generator) seemsSwapped() bool {
+	methods := gen.consumer.Type.(*ast.InterfaceType).Methods.List
+	if len(methods) != 1 || !isMethod(methods[0]) {
+		return false
+	}
+
+	params := paramTypes(methods[0].Type.(*ast.FuncType).Params)
+	return len(params) > 0 && gen.isComposite(params[0])
+}
+
+// takesConsumer tells whether the parameters are the ones of a destructuring
+// method: the passthrough parameters if there are any, the consumer and the
+// accumulator if there's one.
+func (gen *generator) takesConsumer(params *ast.FieldList) bool {
+	got := paramTypes(params)
+	n := len(gen.passthrough)
+
+	acc := gen.accumulatorParam()
+	if acc == "" {
+		return len(got) == n+1 && gen.isConsumerType(got[n])
+	}
+	return len(got) == n+2 && gen.isConsumerType(got[n]) && types.ExprString(got[n+1]) == acc
+}
+
+// isConsumerType tells whether a destructuring method parameter type is the
+// consumer, or a pointer to it.
+func (gen *generator) isConsumerType(typ ast.Expr) bool {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	return types.ExprString(typ) == types.ExprString(gen.instantiate(gen.TypeNames.Consumer))
+}
+
+// consumerByPointer tells whether the destructuring method takes a pointer to
+// the consumer, which then has to be dereferenced to call its methods.
+func (gen *generator) consumerByPointer() bool {
+	return gen.takesConsumerByPointer(gen.destructuring)
+}
+
+// takesConsumerByPointer tells whether a destructuring method takes a pointer
+// to the consumer.
+func (gen *generator) takesConsumerByPointer(method *ast.Field) bool {
+	params := paramTypes(method.Type.(*ast.FuncType).Params)
+	_, ok := params[len(gen.passthrough)].(*ast.StarExpr)
+	return ok
+}
+
+// paramTypes lists the type of each parameter, repeating the type of a group
+// for each name in it.
+func paramTypes(params *ast.FieldList) []ast.Expr {
+	var typs []ast.Expr
+	for _, field := range params.List {
+		typs = append(typs, field.Type)
+		for i := 1; i < len(field.Names); i++ {
+			typs = append(typs, field.Type)
+		}
+	}
+	return typs
+}
+
+// isMethod tells whether an interface element is a method, rather than an
+// embedded interface or a type constraint.
+func isMethod(field *ast.Field) bool {
+	_, ok := field.Type.(*ast.FuncType)
+	return ok && len(field.Names) == 1
+}
+
 func (gen *generator) checkDestructuringMethod(method *ast.Field) error {
 	typ := method.Type.(*ast.FuncType)
 
-	if typ.Params.NumFields() != 1 {
-		return errors.Errorf(
+	acc := gen.accumulatorParam()
+	n := len(gen.passthrough)
+	switch {
+	case n > 0 && acc == "" && typ.Params.NumFields() != n+1:
+		return gen.errorAt(method,
+			"composite method %s should take the %s passthrough arguments followed by the consumer",
+			method.Names[0].Name, strings.Join(gen.passthrough, ", "))
+	case n > 0 && acc != "" && typ.Params.NumFields() != n+2:
+		return gen.errorAt(method,
+			"composite method %s should take the %s passthrough arguments followed by the consumer and a %s accumulator",
+			method.Names[0].Name, strings.Join(gen.passthrough, ", "), acc)
+	case n == 0 && acc == "" && typ.Params.NumFields() != 1:
+		return gen.errorAt(method,
 			"composite method %s has more than one argument",
 			method.Names[0].Name)
+	case n == 0 && acc != "" && typ.Params.NumFields() != 2:
+		return gen.errorAt(method,
+			"composite method %s should take the consumer and a %s accumulator",
+			method.Names[0].Name, acc)
 	}
 
-	argGroup := typ.Params.List[0]
-	argTyp, ok := argGroup.Type.(*ast.Ident)
-	if !ok || argTyp.Name != gen.TypeNames.Consumer {
-		return errors.Errorf(
-			"composite method %s has wrong argument type (should be %s)",
-			method.Names[0].Name, gen.TypeNames.Consumer)
+	params := paramTypes(typ.Params)[n:]
+	if !gen.isConsumerType(params[0]) {
+		want := types.ExprString(gen.instantiate(gen.TypeNames.Consumer))
+		return gen.errorAt(params[0],
+			"composite method %s has wrong argument type (should be %s or *%s)",
+			method.Names[0].Name, want, want)
+	}
+	if acc != "" && types.ExprString(params[1]) != acc {
+		return gen.errorAt(params[1],
+			"composite method %s has wrong accumulator type (should be %s)",
+			method.Names[0].Name, acc)
 	}
 
-	if typ.Results.NumFields() > 0 {
-		return errors.Errorf(
-			"composite method %s has %d results (should have none)",
+	if typ.Results.NumFields() > 1 {
+		return gen.errorAt(typ.Results,
+			"composite method %s has %d results (should have at most one)",
 			method.Names[0].Name, typ.Results.NumFields())
 	}
 
+	if gen.FallibleVisitor && (typ.Results.NumFields() != 1 || types.ExprString(typ.Results.List[0].Type) != "error") {
+		return gen.errorAt(method,
+			"composite method %s should return error, since the visitor is fallible",
+			method.Names[0].Name)
+	}
+
 	return nil
 }
@@ -6,10 +6,30 @@ package irgen
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/szabba/irgen/internal/linediff"
 )
 
+// When the environment variable IRGEN_UPDATE is set to 1, reference files are
+// overwritten with the generated output instead of being compared to it.
+func updateReferences() bool {
+	return os.Getenv("IRGEN_UPDATE") == "1"
+}
+
 func (config Config) compareOuputToReferenceFile(t *testing.T, reffile string) {
 	t.Helper()
 
@@ -18,6 +38,88 @@ func (config Config) compareOuputToReferenceFile(t *testing.T, reffile string) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	if updateReferences() {
+		err := ioutil.WriteFile(reffile, buf.Bytes(), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	ref, err := ioutil.ReadFile(reffile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: The comparison below is formatting-insensitive, so whether the
+	// output is left the way gofmt would have it gets checked separately.
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(formatted, buf.Bytes()) {
+		t.Errorf("output is not gofmt-clean (-want +got):\n%s", lineDiff(string(formatted), buf.String()))
+	}
+
+	got, want := normalizeSource(t, buf.Bytes()), normalizeSource(t, ref)
+	if got != want {
+		t.Errorf("output differs from %s (-want +got):\n%s", reffile, lineDiff(want, got))
+	}
+}
+
+// configFromSource writes src into a fresh temporary directory and returns a
+// config pointing at it. The type names are left for the caller to fill in.
+func configFromSource(t *testing.T, src string) Config {
+	t.Helper()
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	err = ioutil.WriteFile(filepath.Join(dir, "src.go"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Config{Directory: dir, PackageName: f.Name.Name}
+}
+
+// normalizeSource gofmts src and strips trailing whitespace from every line,
+// so that cosmetic differences don't count as mismatches.
+func normalizeSource(t *testing.T, src []byte) string {
+	t.Helper()
+
+	formatted, err := format.Source(src)
+	if err != nil {
+		t.Fatalf("can't format source: %s\n%s", err, src)
+	}
+
+	lines := strings.Split(string(formatted), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lineDiff renders a minimal line-based diff between want and got, with
+// removed lines prefixed by "-" and added lines by "+".
+func lineDiff(want, got string) string {
+	var out bytes.Buffer
+	for _, edit := range linediff.Lines(strings.Split(want, "\n"), strings.Split(got, "\n")) {
+		fmt.Fprintf(&out, "%c%s\n", edit.Op, edit.Line)
+	}
+	return out.String()
+}
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff("a\nb\nc", "a\nx\nc")
+	want := " a\n-b\n+x\n c\n"
+	if got != want {
+		t.Errorf("got diff\n%s\nwant\n%s", got, want)
+	}
 }
 
 func TestIntExpr(t *testing.T) {
@@ -37,8 +139,8 @@ func TestTypes(t *testing.T) {
 	// irgen used to generate a receiver name that was the lowercase version
 	// of the composite type. This meant if the composite type name was an
 	// upper-case version of a Go keyword, the code would not compile.
-	// Therefore irgen now simply uses the type name. If that is not a valid
-	// type name that is the fault of the user, not irgen.
+	// Therefore irgen now only lowercases the first letter, which can't be
+	// a keyword.
 
 	reference := filepath.FromSlash("./internal/test_cases/types/ref.go")
 
@@ -51,3 +153,4156 @@ func TestTypes(t *testing.T) {
 
 	config.compareOuputToReferenceFile(t, reference)
 }
+
+func TestFold(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/fold/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/fold"),
+		PackageName: "fold",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestFoldRejectsMultipleResults(t *testing.T) {
+	config := configFromSource(t, `package fold
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) (int, error)
+}
+
+type ExprConsumer interface {
+	Lit(N int) (int, error)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	err := config.Generate(ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "at most one") {
+		t.Errorf("got error %v, want one about having at most one result", err)
+	}
+}
+
+func TestFoldRejectsMismatchedResults(t *testing.T) {
+	config := configFromSource(t, `package fold
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) int
+}
+
+type ExprConsumer interface {
+	Lit(N int) string
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	err := config.Generate(ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "should return int") {
+		t.Errorf("got error %v, want one about the result type", err)
+	}
+}
+
+func TestImports(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/imports/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/imports"),
+		PackageName: "imports",
+	}
+	config.TypeNames.Composite = "Job"
+	config.TypeNames.Consumer = "JobHandler"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestGeneratedImportNameClash(t *testing.T) {
+	config := configFromSource(t, `package clash
+
+import json "encoding/xml"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Raw(Name json.Name)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateJSON = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"\tirgenjson \"encoding/json\"\n",
+		"\tjson \"encoding/xml\"\n",
+		"return irgenjson.Marshal(",
+		"Name json.Name\n",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestDotImports(t *testing.T) {
+	config := configFromSource(t, `package dot
+
+import (
+	. "strings"
+	"os"
+)
+
+var _ = os.Args
+
+type Text interface {
+	FeedTo(cons TextConsumer)
+}
+
+type TextConsumer interface {
+	Built(With *Builder)
+}
+`)
+	config.TypeNames.Composite = "Text"
+	config.TypeNames.Consumer = "TextConsumer"
+
+	var buf bytes.Buffer
+	err := config.Generate(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `. "strings"`) {
+		t.Errorf("dot import missing from output:\n%s", out)
+	}
+	if strings.Contains(out, `"os"`) {
+		t.Errorf("unused import present in output:\n%s", out)
+	}
+}
+
+func TestDefaultPackageName(t *testing.T) {
+	for path, want := range map[string]string{
+		"context":               "context",
+		"go/ast":                "ast",
+		"gopkg.in/yaml.v2":      "yaml",
+		"example.com/mod/v3":    "mod",
+		"github.com/pkg/errors": "errors",
+	} {
+		if got := defaultPackageName(path); got != want {
+			t.Errorf("defaultPackageName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestConstructors(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/constructors/ref.go")
+
+	config := Config{
+		Directory:            filepath.FromSlash("internal/test_cases/constructors"),
+		PackageName:          "constructors",
+		Constructors:         true,
+		ConcreteConstructors: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestParamName(t *testing.T) {
+	for field, want := range map[string]string{
+		"N":       "n",
+		"Left":    "left",
+		"URL":     "url",
+		"URLPath": "urlPath",
+		"Type":    "type_",
+	} {
+		if got := paramName(field); got != want {
+			t.Errorf("paramName(%q) = %q, want %q", field, got, want)
+		}
+	}
+}
+
+func TestEmbedding(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/embedding/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/embedding"),
+		PackageName: "embedding",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestEmbeddingFromOtherPackage(t *testing.T) {
+	config := configFromSource(t, `package embedding
+
+import "fmt"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	fmt.Stringer
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	err := config.Generate(ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "fmt.Stringer") {
+		t.Errorf("got error %v, want one about fmt.Stringer", err)
+	}
+}
+
+func TestEmbeddingCycle(t *testing.T) {
+	config := configFromSource(t, `package embedding
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	OtherConsumer
+	Lit(N int)
+}
+
+type OtherConsumer interface {
+	ExprConsumer
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	err := config.Generate(ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "embeds itself") {
+		t.Errorf("got error %v, want one about an embedding cycle", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/match/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/match"),
+		PackageName:   "match",
+		GenerateMatch: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestVariantMethodsDontShareNodes(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/types"),
+		PackageName: "types",
+	}
+	config.TypeNames.Composite = "Type"
+	config.TypeNames.Consumer = "TypeConsumer"
+
+	gen := &generator{Config: config, fset: token.NewFileSet()}
+	err := gen.parsePackage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gen.generateAST()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var funs []*ast.FuncDecl
+	for _, decl := range gen.file.Decls {
+		if fun, ok := decl.(*ast.FuncDecl); ok {
+			funs = append(funs, fun)
+		}
+	}
+	if len(funs) != 2 {
+		t.Fatalf("got %d methods, want 2", len(funs))
+	}
+
+	a, b := funs[0], funs[1]
+	switch {
+	case a.Name == b.Name:
+		t.Error("methods share the name node")
+	case a.Type == b.Type:
+		t.Error("methods share the signature node")
+	case a.Type.Params == b.Type.Params:
+		t.Error("methods share the parameter list")
+	case a.Type.Params.List[0] == b.Type.Params.List[0]:
+		t.Error("methods share the consumer parameter")
+	case a.Type.Params.List[0].Names[0] == b.Type.Params.List[0].Names[0]:
+		t.Error("methods share the consumer parameter name")
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/docs/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/docs"),
+		PackageName: "docs",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+
+	var buf bytes.Buffer
+	err := config.Generate(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "// Lit is an integer literal.\ntype Lit struct {"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output does not contain %q:\n%s", want, buf.String())
+	}
+}
+
+func TestGenerateBytesMatchesGenerate(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := "// Code generated by irgen; DO NOT EDIT.\n"
+	if !bytes.HasPrefix(src, []byte(header)) {
+		t.Errorf("generated source does not start with %q:\n%s", header, src)
+	}
+
+	var buf bytes.Buffer
+	err = config.Generate(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(src, buf.Bytes()) {
+		t.Errorf("GenerateBytes and Generate disagree (-bytes +writer):\n%s", lineDiff(string(src), buf.String()))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	for _, name := range []string{"intexpr", "types", "imports"} {
+		t.Run(name, func(t *testing.T) {
+			config := Config{
+				Directory:   filepath.Join("internal", "test_cases", name),
+				PackageName: name,
+				Verify:      true,
+			}
+			switch name {
+			case "intexpr":
+				config.TypeNames.Composite = "Expr"
+				config.TypeNames.Consumer = "ExprConsumer"
+			case "types":
+				config.TypeNames.Composite = "Type"
+				config.TypeNames.Consumer = "TypeConsumer"
+			case "imports":
+				config.TypeNames.Composite = "Job"
+				config.TypeNames.Consumer = "JobHandler"
+			}
+
+			_, err := config.GenerateBytes()
+			if err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestVerifyCatchesUndefinedTypes(t *testing.T) {
+	config := configFromSource(t, `package verify
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N Undefined)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Verify = true
+
+	_, err := config.GenerateBytes()
+	if err == nil {
+		t.Fatal("no error returned")
+	}
+	if !strings.Contains(err.Error(), "Undefined") || !strings.Contains(err.Error(), ".go:") {
+		t.Errorf("got error %q, want one mentioning Undefined and a position", err)
+	}
+}
+
+func TestGeneric(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/generic/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/generic"),
+		PackageName:   "generic",
+		Constructors:  true,
+		GenerateMatch: true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Tree"
+	config.TypeNames.Consumer = "TreeConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestGenericTypeParamsMustMatch(t *testing.T) {
+	config := configFromSource(t, `package generic
+
+type Tree[T any] interface {
+	FeedTo(cons TreeConsumer[T])
+}
+
+type TreeConsumer[T comparable] interface {
+	Leaf(Value T)
+}
+`)
+	config.TypeNames.Composite = "Tree"
+	config.TypeNames.Consumer = "TreeConsumer"
+
+	err := config.Generate(ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "should be the same") {
+		t.Errorf("got error %v, want one about mismatched type parameters", err)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/equal/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/equal"),
+		PackageName:   "equal",
+		GenerateEqual: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestString(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/stringer/ref.go")
+
+	config := Config{
+		Directory:      filepath.FromSlash("internal/test_cases/stringer"),
+		PackageName:    "stringer",
+		GenerateString: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestVariantPrefixAndSuffix(t *testing.T) {
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/prefix"),
+		PackageName:  "prefix",
+		Constructors: true,
+	}
+
+	exprs := config
+	exprs.TypeNames.Composite = "Expr"
+	exprs.TypeNames.Consumer = "ExprConsumer"
+	exprs.TypeNames.VariantPrefix = "Expr"
+	exprs.compareOuputToReferenceFile(t, filepath.FromSlash("./internal/test_cases/prefix/expr_ref.go"))
+
+	patterns := config
+	patterns.TypeNames.Composite = "Pattern"
+	patterns.TypeNames.Consumer = "PatternConsumer"
+	patterns.TypeNames.VariantSuffix = "Pattern"
+	patterns.compareOuputToReferenceFile(t, filepath.FromSlash("./internal/test_cases/prefix/pattern_ref.go"))
+}
+
+func TestMultiplePairs(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/prefix"),
+		PackageName: "prefix",
+	}
+	config.TypeNames = TypeNames{Composite: "Expr", Consumer: "ExprConsumer", VariantPrefix: "Expr"}
+	config.Pairs = []TypeNames{{Composite: "Pattern", Consumer: "PatternConsumer", VariantSuffix: "Pattern"}}
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"type ExprLit struct", "type ExprAdd struct",
+		"type LitPattern struct", "type WildcardPattern struct",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+
+	if n := bytes.Count(src, []byte("package prefix")); n != 1 {
+		t.Errorf("got %d package clauses, want 1:\n%s", n, src)
+	}
+}
+
+func TestMultiplePairsShareImports(t *testing.T) {
+	config := configFromSource(t, `package shared
+
+import "time"
+
+type Event interface {
+	FeedTo(cons EventConsumer)
+}
+
+type EventConsumer interface {
+	Started(At time.Time)
+}
+
+type Timer interface {
+	FeedTo(cons TimerConsumer)
+}
+
+type TimerConsumer interface {
+	Ticked(After time.Duration)
+}
+`)
+	config.TypeNames = TypeNames{Composite: "Event", Consumer: "EventConsumer"}
+	config.Pairs = []TypeNames{{Composite: "Timer", Consumer: "TimerConsumer"}}
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := bytes.Count(src, []byte(`"time"`)); n != 1 {
+		t.Errorf("got %d imports of time, want 1:\n%s", n, src)
+	}
+}
+
+func TestGenerateFromParsedPackage(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, filepath.FromSlash("internal/test_cases/prefix"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs["prefix"]
+
+	for _, names := range []TypeNames{
+		{Composite: "Expr", Consumer: "ExprConsumer", VariantPrefix: "Expr"},
+		{Composite: "Pattern", Consumer: "PatternConsumer", VariantSuffix: "Pattern"},
+	} {
+		t.Run(names.Composite, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Generate(pkg, fset, names, &buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			config := Config{
+				Directory:   filepath.FromSlash("internal/test_cases/prefix"),
+				PackageName: "prefix",
+				TypeNames:   names,
+			}
+			want, err := config.GenerateBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("got\n%s\nwant the same as when parsing the directory:\n%s", buf.Bytes(), want)
+			}
+		})
+	}
+}
+
+func TestGenerateFromParsedPackageRepeatedly(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, filepath.FromSlash("internal/test_cases/outpkg"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := Config{OutputPackageName: "expr_test", Constructors: true, Verify: true}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	// NOTE: The generated code refers to the source types through an
+	// import, which must not leak into the parsed package.
+	first, err := config.GenerateFromPackage(pkgs["expr"], fset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := config.GenerateFromPackage(pkgs["expr"], fset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(first, second) {
+		t.Errorf("the second run generated\n%s\ninstead of\n%s", second, first)
+	}
+}
+
+func TestGenerateFromParsedPackageChecksName(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, filepath.FromSlash("internal/test_cases/intexpr"), nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := Config{PackageName: "other"}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err = config.GenerateFromPackage(pkgs["intexpr"], fset)
+	want := "package intexpr given, but the config names package other"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestMethodName(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/accept/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/accept"),
+		PackageName: "accept",
+		MethodName:  "Accept",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprVisitor"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestMethodNameMismatch(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/accept"),
+		PackageName: "accept",
+		MethodName:  "Visit",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprVisitor"
+
+	err := config.Generate(ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "named Accept, not Visit") {
+		t.Errorf("got error %v, want one about the method name mismatch", err)
+	}
+}
+
+func TestDuplicateVariants(t *testing.T) {
+	config := configFromSource(t, `package dup
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type LitConsumer interface {
+	Lit(N int)
+}
+
+type ExprConsumer interface {
+	LitConsumer
+	Var(Name string)
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	err := config.Generate(ioutil.Discard)
+	if err == nil || !strings.Contains(err.Error(), "consumer method Lit is declared more than once") {
+		t.Errorf("got error %v, want one about Lit being repeated", err)
+	}
+}
+
+func TestSealed(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/sealed/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/sealed"),
+		PackageName: "sealed",
+		Sealed:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestTagMethod(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/tagmethod/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/tagmethod"),
+		PackageName: "tagmethod",
+		GenerateTag: true,
+		Verify:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.TypeNames.VariantPrefix = "Expr"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestGroupedTypeDecl(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/grouped/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/grouped"),
+		PackageName:   "grouped",
+		Sealed:        true,
+		Constructors:  true,
+		GenerateEqual: true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestSealedWithoutDeclaredMarker(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+		Sealed:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "func (*Lit) isExpr() {}"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestSealedByEmbeddedMarker(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	exprSealed
+	FeedTo(cons ExprConsumer)
+}
+
+type exprSealed interface {
+	sealed()
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Sealed = true
+	config.EmitAssertions = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"func (*Lit) sealed() {}", "func (*Add) sealed() {}"} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+	if bytes.Contains(src, []byte("isExpr")) {
+		t.Errorf("output declares a marker method besides the embedded one:\n%s", src)
+	}
+}
+
+func TestMethodsOnly(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/handwritten/ref.go")
+
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/handwritten"),
+		PackageName:  "handwritten",
+		MethodsOnly:  true,
+		Constructors: true,
+		Verify:       true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestMethodsOnlyMismatch(t *testing.T) {
+	for _, tt := range []struct {
+		name, variants, want string
+	}{
+		{"Missing", "type Lit struct{ N int }", "consumer method Add needs a struct Add in package expr"},
+		{"NotStruct", "type Lit struct{ N int }\ntype Add []Expr", "type Add is not a struct, but consumer method Add needs it to be one"},
+		{"MissingField", "type Lit struct{}\ntype Add struct{ Left, Right Expr }", "struct Lit has no field N, which consumer method Lit needs"},
+		{"FieldType", "type Lit struct{ N int64 }\ntype Add struct{ Left, Right Expr }", "field N of struct Lit has type int64, but consumer method Lit needs int"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+
+`+tt.variants+`
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.MethodsOnly = true
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionAndChannelFields(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/funcs/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/funcs"),
+		PackageName: "funcs",
+		Verify:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestNestedFunctionParamsNeedNotBeExported(t *testing.T) {
+	config := configFromSource(t, `package funcs
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lambda(Body func(arg Expr) (result Expr))
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Body func(arg Expr) (result Expr)"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestImportOrder(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/importorder/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/importorder"),
+		PackageName: "importorder",
+	}
+	config.TypeNames.Composite = "Event"
+	config.TypeNames.Consumer = "EventConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "import (\n\t\"context\"\n\t\"time\"\n\n\t\"github.com/szabba/irgen\"\n)\n"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain the import block\n%s\nin:\n%s", want, src)
+	}
+}
+
+func TestUnusedImportsArePruned(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	gen := &generator{Config: config, fset: token.NewFileSet()}
+	gen.imports = map[string]importSpec{"os": {Path: "os"}}
+
+	err := gen.parsePackage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gen.generateAST()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, decl := range gen.file.Decls {
+		if declToken(decl) == token.IMPORT {
+			t.Errorf("unused import not pruned")
+		}
+	}
+}
+
+func TestSingleImportHasNoParens(t *testing.T) {
+	config := configFromSource(t, `package single
+
+import "time"
+
+type Event interface {
+	FeedTo(cons EventConsumer)
+}
+
+type EventConsumer interface {
+	Started(At time.Time)
+}
+`)
+	config.TypeNames.Composite = "Event"
+	config.TypeNames.Consumer = "EventConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "import \"time\"\n"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestBuildTags(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+		BuildTags:   []string{"integration", "!race"},
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "// Code generated by irgen; DO NOT EDIT.\n\n" +
+		"//go:build integration && !race\n" +
+		"// +build integration,!race\n\n" +
+		"package intexpr\n"
+	if !bytes.HasPrefix(src, []byte(want)) {
+		t.Fatalf("output does not start with\n%s\ngot:\n%s", want, src)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, group := range f.Comments {
+		if group.Pos() > f.Package {
+			break
+		}
+		for _, comment := range group.List {
+			if constraint.IsGoBuild(comment.Text) {
+				found = true
+				_, err := constraint.Parse(comment.Text)
+				if err != nil {
+					t.Errorf("invalid build constraint %q: %s", comment.Text, err)
+				}
+			}
+		}
+	}
+	if !found {
+		t.Error("no //go:build constraint before the package clause")
+	}
+}
+
+func TestInvalidBuildTags(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+		BuildTags:   []string{"foo &&"},
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "invalid build tags") {
+		t.Errorf("got error %v, want one about invalid build tags", err)
+	}
+}
+
+func TestOutputPackageName(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/outpkg/ref_test.go")
+
+	config := Config{
+		Directory:         filepath.FromSlash("internal/test_cases/outpkg"),
+		PackageName:       "expr",
+		OutputPackageName: "expr_test",
+		Constructors:      true,
+		GenerateMatch:     true,
+		Verify:            true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestOutputPackageNameRequiresExportedTypes(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Neg(Of expr)
+}
+`)
+	err := ioutil.WriteFile(filepath.Join(config.Directory, "go.mod"), []byte("module example.com/expr\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.OutputPackageName = "other"
+	config.TypeNames.Composite = "expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err = config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "expr is not exported") {
+		t.Errorf("got error %v, want one about expr not being exported", err)
+	}
+}
+
+func TestFieldTags(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/tags/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/tags"),
+		PackageName: "tags",
+	}
+	config.TypeNames.Composite = "Event"
+	config.TypeNames.Consumer = "EventConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestBadFieldTags(t *testing.T) {
+	for _, tc := range []struct {
+		method string
+		want   string
+	}{
+		{"Key(Code rune) //irgen:tag Name json:\"name\"", `no parameter "Name"`},
+		{"Key(Code rune) //irgen:tag Code json", "bad tag for parameter Code"},
+		{"Key(Code rune) //irgen:tag Code", "bad tag for parameter Code"},
+	} {
+		config := configFromSource(t, `package tags
+
+type Event interface {
+	FeedTo(cons EventConsumer)
+}
+
+type EventConsumer interface {
+	`+tc.method+`
+}
+`)
+		config.TypeNames.Composite = "Event"
+		config.TypeNames.Consumer = "EventConsumer"
+
+		_, err := config.GenerateBytes()
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got error %v, want one containing %q", tc.method, err, tc.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/json/ref.go")
+
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/json"),
+		PackageName:  "json",
+		GenerateJSON: true,
+		Verify:       true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestJSONTypeKeyClash(t *testing.T) {
+	for _, tt := range []struct {
+		name, method, want string
+	}{
+		{"Named", "Cast(Type string, Of Expr)", `field Type of variant Cast would be marshalled as "Type"`},
+		{"Tagged", "Cast(To string, Of Expr) //irgen:tag To json:\"type,omitempty\"", `field To of variant Cast would be marshalled as "type"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	`+tt.method+`
+}
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.GenerateJSON = true
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFieldNamedType(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Cast(Type string, Of Expr) //irgen:tag Type json:"to"
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateJSON = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Type_ string `json:\"type\"`"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestErrorsHavePositions(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(left, right Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err == nil {
+		t.Fatal("no error for unexported argument names")
+	}
+
+	want := filepath.Join(config.Directory, "src.go") + ":9:6: "
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not contain position %q", err, want)
+	}
+}
+
+func TestExtraCompositeMethods(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+import "go/token"
+
+type Expr interface {
+	Pos() token.Pos
+	FeedTo(cons ExprConsumer)
+	String() string
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"func (e *Lit) FeedTo(", "func (e *Add) FeedTo("} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+	for _, unwanted := range []string{"Pos()", "String()", "go/token"} {
+		if bytes.Contains(src, []byte(unwanted)) {
+			t.Errorf("output contains %q:\n%s", unwanted, src)
+		}
+	}
+}
+
+func TestNoDestructuringMethod(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	Pos() int
+	String() string
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "no method with a single ExprConsumer argument") {
+		t.Errorf("got error %v, want one about a missing destructuring method", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var summary bytes.Buffer
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+		DryRun:      true,
+		Summary:     &summary,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	var out bytes.Buffer
+	err := config.Generate(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Len() > 0 {
+		t.Errorf("dry run wrote %d bytes:\n%s", out.Len(), out.Bytes())
+	}
+
+	want := "Expr/ExprConsumer: Lit, Var, Add, Sub, Mul (FeedTo)\n"
+	if summary.String() != want {
+		t.Errorf("got summary %q, want %q", summary.String(), want)
+	}
+}
+
+func TestDryRunReportsErrors(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(n int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.DryRun = true
+	config.Summary = ioutil.Discard
+
+	var out bytes.Buffer
+	err := config.Generate(&out)
+	if err == nil {
+		t.Error("no error for an unexported argument name")
+	}
+	if out.Len() > 0 {
+		t.Errorf("dry run wrote %d bytes:\n%s", out.Len(), out.Bytes())
+	}
+}
+
+func TestConstructorNameCollision(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	NewLit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.ConcreteConstructors = true
+
+	_, err := config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "same name as variant NewLit") {
+		t.Errorf("got error %v, want one about colliding with variant NewLit", err)
+	}
+}
+
+func TestSplitAcrossFiles(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/split/ref.go")
+
+	config := Config{
+		Directory:      filepath.FromSlash("internal/test_cases/split"),
+		PackageName:    "split",
+		GenerateEqual:  true,
+		GenerateString: true,
+		Verify:         true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestSplitAcrossFilesWithDifferentImportNames(t *testing.T) {
+	config := configFromSource(t, `package split
+
+import tok "go/token"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) tok.Pos
+}
+`)
+	err := ioutil.WriteFile(filepath.Join(config.Directory, "b.go"), []byte(`package split
+
+import "go/token"
+
+type ExprConsumer interface {
+	Lit(N int) token.Pos
+}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err = config.GenerateBytes()
+	want := `package "go/token" is imported as token here, but as tok elsewhere`
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestOutputIsDeterministic(t *testing.T) {
+	config := Config{
+		Directory:      filepath.FromSlash("internal/test_cases/split"),
+		PackageName:    "split",
+		Constructors:   true,
+		GenerateMatch:  true,
+		GenerateEqual:  true,
+		GenerateString: true,
+		GenerateKind:   true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	first, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: Map iteration order is random, so a single extra run could
+	// match by chance.
+	for i := 0; i < 10; i++ {
+		src, err := config.GenerateBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(src, first) {
+			t.Fatalf("run %d generated different code:\n%s\nthan the first one:\n%s", i+2, src, first)
+		}
+	}
+}
+
+func TestErrorsFollowFileOrder(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+//irgen:later
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+`)
+	err := ioutil.WriteFile(filepath.Join(config.Directory, "a.go"), []byte(`package expr
+
+//irgen:first
+type ExprConsumer interface {
+	Lit(N int)
+}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	for i := 0; i < 10; i++ {
+		_, err = config.GenerateBytes()
+		want := "unknown directive //irgen:first"
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("got error %v, want one containing %q", err, want)
+		}
+	}
+}
+
+func TestHeader(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+		Header:      "Copyright 2026 Somebody.\n\n// All rights reserved.\n",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "// Copyright 2026 Somebody.\n//\n// All rights reserved.\n\n" +
+		"// Code generated by irgen; DO NOT EDIT.\n\n" +
+		"package intexpr\n"
+	if !bytes.HasPrefix(src, []byte(want)) {
+		t.Fatalf("output does not start with\n%s\ngot:\n%s", want, src)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ast.IsGenerated(f) {
+		t.Error("the output is not recognized as generated code")
+	}
+}
+
+func TestOmitHeader(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+		Header:      "Copyright 2026 Somebody.",
+		OmitHeader:  true,
+		Verify:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(src, []byte("Code generated")) {
+		t.Errorf("output contains the generated code marker:\n%s", src)
+	}
+
+	want := "// Copyright 2026 Somebody.\n\npackage intexpr\n"
+	if !bytes.HasPrefix(src, []byte(want)) {
+		t.Fatalf("output does not start with\n%s\ngot:\n%s", want, src)
+	}
+}
+
+func TestFoldFunction(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/foldfunc/ref.go")
+
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/foldfunc"),
+		PackageName:  "foldfunc",
+		GenerateFold: true,
+		Verify:       true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestGenericFoldFunction(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr[R any] interface {
+	FeedTo(cons ExprConsumer[R])
+}
+
+type ExprConsumer[R any] interface {
+	Lit(Value R)
+	Add(Left, Right Expr[R])
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateFold = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "func FoldExpr[R any, R_ any](e Expr[R], h ExprHandlers[R, R_]) R_ {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestFoldFunctionWithParamNamedR(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(L, R Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateFold = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "func (f *exprFolder[R_]) Add(L, R Expr) {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestSyntheticNamesDontCollide(t *testing.T) {
+	for _, composite := range []string{"Expr", "Item", "consumer", "that", "other", "ok"} {
+		config := configFromSource(t, strings.NewReplacer("COMPOSITE", composite).Replace(`package expr
+
+type COMPOSITE interface {
+	FeedTo(cons Consumer)
+}
+
+type Consumer interface {
+	Lit(N int)
+	Weird(Consumer int)
+	Nested(Of COMPOSITE)
+}
+`))
+		config.TypeNames.Composite = composite
+		config.TypeNames.Consumer = "Consumer"
+		config.Constructors = true
+		config.GenerateMatch = true
+		config.GenerateEqual = true
+		config.GenerateString = true
+		config.GenerateMap = true
+		config.GenerateSeq = true
+		config.GenerateHash = true
+		config.GenerateWalkParent = true
+		config.Verify = true
+
+		_, err := config.GenerateBytes()
+		if err != nil {
+			t.Errorf("composite %s: %s", composite, err)
+		}
+	}
+}
+
+func TestVariadic(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/variadic/ref.go")
+
+	config := Config{
+		Directory:      filepath.FromSlash("internal/test_cases/variadic"),
+		PackageName:    "variadic",
+		Constructors:   true,
+		GenerateMatch:  true,
+		GenerateEqual:  true,
+		GenerateString: true,
+		GenerateFold:   true,
+		Verify:         true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestAssertions(t *testing.T) {
+	config := Config{
+		Directory:      filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName:    "intexpr",
+		EmitAssertions: true,
+		Verify:         true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, variant := range []string{"Lit", "Var", "Add", "Sub", "Mul"} {
+		want := fmt.Sprintf("_ Expr = (*%s)(nil)", variant)
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenericAssertions(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr[T any] interface {
+	FeedTo(cons ExprConsumer[T])
+}
+
+type ExprConsumer[T any] interface {
+	Lit(Value T)
+	Add(Left, Right Expr[T])
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.EmitAssertions = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "_ Expr[T] = (*Lit[T])(nil)"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestAssertionsCatchMissingMethods(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+	Pos() int
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.EmitAssertions = true
+	config.Verify = true
+
+	_, err := config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "missing method Pos") {
+		t.Errorf("got error %v, want one about the missing Pos method", err)
+	}
+}
+
+func TestCommentsDontChangeOutput(t *testing.T) {
+	generate := func(src string) []byte {
+		t.Helper()
+
+		config := configFromSource(t, src)
+		config.TypeNames.Composite = "Expr"
+		config.TypeNames.Consumer = "ExprConsumer"
+		config.Constructors = true
+		config.GenerateMatch = true
+
+		out, err := config.GenerateBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	plain := generate(`package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+`)
+
+	commented := generate(`// Package expr has comments all over.
+package expr
+
+/* A block comment. */
+
+// An expression.
+type Expr interface {
+	// Destructures the expression.
+	FeedTo(cons /* the consumer */ ExprConsumer) // Trailing.
+}
+
+type ExprConsumer interface {
+	Lit(N int) // A literal.
+	// Between methods.
+
+	Add(Left, /* right */ Right Expr)
+	// At the end.
+}
+`)
+
+	// NOTE: Only the doc comments of variants are meant to make it to the
+	// output, and this source has none.
+	if !bytes.Equal(plain, commented) {
+		t.Errorf("comments changed the output:\n%s", lineDiff(string(plain), string(commented)))
+	}
+}
+
+func TestUnknownDirective(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int) //irgen:tga N json:"n"
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "unknown directive //irgen:tga") {
+		t.Errorf("got error %v, want one about an unknown directive", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/files/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/files"),
+		PackageName: "files",
+		Files:       []string{"expr.go"},
+		Verify:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestFilesLeftOutWouldFail(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/files"),
+		PackageName: "files",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "excluded.go") {
+		t.Errorf("got error %v, want one about excluded.go", err)
+	}
+}
+
+func TestKind(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/kind/ref.go")
+
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/kind"),
+		PackageName:  "kind",
+		GenerateKind: true,
+		Verify:       true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestImportedConsumer(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/imported/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/imported"),
+		PackageName:   "imported",
+		GenerateMatch: true,
+		GenerateEqual: true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "shared.ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestImportedConsumerNeedsImport(t *testing.T) {
+	config := configFromSource(t, `package imported
+
+type Expr interface {
+	FeedTo(cons shared.ExprConsumer)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "shared.ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	want := "package qualifier shared of consumer type shared.ExprConsumer does not match any import"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestImportedPackage(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"WithExternalTests", map[string]string{"a.go": "package foo", "a_test.go": "package foo_test"}, "foo"},
+		{"NamedAfterPath", map[string]string{"a.go": "package foo", "b.go": "package main"}, "foo"},
+		{"Ambiguous", map[string]string{"a.go": "package bar", "b.go": "package baz"}, "can't tell which of the packages bar, baz is meant"},
+		{"OnlyTests", map[string]string{"a_test.go": "package foo_test"}, "no package"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, src := range tt.files {
+				err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src+"\n"), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			pkgs, err := parser.ParseDir(token.NewFileSet(), dir, nil, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			pkg, err := importedPackage(pkgs, "example.com/foo")
+			if err != nil {
+				got = err.Error()
+			} else {
+				got = pkg.Name
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "src.go")
+	err := ioutil.WriteFile(file, []byte("package src\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := Config{Directory: dir, PackageName: "src"}
+	valid.TypeNames = TypeNames{Composite: "Expr", Consumer: "ExprConsumer"}
+
+	for _, tt := range []struct {
+		name   string
+		modify func(cfg *Config)
+		want   string
+	}{
+		{"Valid", func(cfg *Config) {}, ""},
+		{"NoDirectory", func(cfg *Config) { cfg.Directory = "" }, "no directory given"},
+		{"MissingDirectory", func(cfg *Config) { cfg.Directory = filepath.Join(dir, "missing") }, "can't be read"},
+		{"DirectoryIsAFile", func(cfg *Config) { cfg.Directory = file }, "is not a directory"},
+		{"NoPackageName", func(cfg *Config) { cfg.PackageName = "" }, "no package name given"},
+		{"NoPairs", func(cfg *Config) { cfg.TypeNames = TypeNames{} }, "no composite/consumer pairs given"},
+		{"NoComposite", func(cfg *Config) { cfg.TypeNames.Composite = "" }, "pair 1 has no composite type name"},
+		{"NoConsumer", func(cfg *Config) { cfg.TypeNames.Consumer = "" }, "pair 1 has no consumer type name"},
+		{"NoConsumerInLaterPair", func(cfg *Config) {
+			cfg.Pairs = []TypeNames{{Composite: "Stmt"}}
+		}, "pair 2 has no consumer type name"},
+		{"TypesAndMethodsOnly", func(cfg *Config) {
+			cfg.TypesOnly, cfg.MethodsOnly = true, true
+		}, "only the types and only the methods can't both be generated"},
+		{"TypesOnlyWithFeatures", func(cfg *Config) {
+			cfg.TypesOnly, cfg.GenerateEqual = true, true
+		}, "nothing besides the variant types can be generated when only the types are"},
+		{"BadLintSuppression", func(cfg *Config) {
+			cfg.LintSuppressions = []string{"gocritic", "all linters"}
+		}, `lint suppression "all linters" is not a linter name`},
+		{"SameTypes", func(cfg *Config) {
+			cfg.TypeNames.Consumer = cfg.TypeNames.Composite
+		}, "pair 1 names Expr as both the composite and the consumer type, which must differ"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("got error %v, want none", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	var cfg Config
+	cfg.TypeNames.Composite = "Expr"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	for _, want := range []string{"no directory given", "no package name given", "pair 1 has no consumer type name"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %v, want one containing %q", err, want)
+		}
+	}
+}
+
+func TestGenerateValidates(t *testing.T) {
+	var cfg Config
+	_, err := cfg.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "invalid config") {
+		t.Errorf("got error %v, want an invalid config one", err)
+	}
+}
+
+func TestErrNotInterface(t *testing.T) {
+	for _, tt := range []struct {
+		name, src string
+	}{
+		{"Composite", `package expr
+
+type Expr struct{}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`},
+		{"Consumer", `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer func(N int)
+`},
+		{"Embedded", `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Leaves
+}
+
+type Leaves struct{}
+`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, tt.src)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+
+			_, err := config.GenerateBytes()
+			if !errors.Is(err, ErrNotInterface) {
+				t.Errorf("got error %v, want one matching ErrNotInterface", err)
+			}
+		})
+	}
+}
+
+func TestErrMethodCount(t *testing.T) {
+	for _, tt := range []struct {
+		name, composite string
+	}{
+		{"None", `type Expr interface {
+	String() string
+	Pos() int
+}`},
+		{"Several", `type Expr interface {
+	FeedTo(cons ExprConsumer)
+	Accept(cons ExprConsumer)
+}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, "package expr\n\n"+tt.composite+`
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+
+			_, err := config.GenerateBytes()
+			if !errors.Is(err, ErrMethodCount) {
+				t.Errorf("got error %v, want one matching ErrMethodCount", err)
+			}
+		})
+	}
+}
+
+func TestBase(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/base/ref.go")
+
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/base"),
+		PackageName:  "base",
+		GenerateBase: true,
+		Verify:       true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestAccumulator(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/accumulator/ref.go")
+
+	config := Config{
+		Directory:       filepath.FromSlash("internal/test_cases/accumulator"),
+		PackageName:     "accumulator",
+		AccumulatorType: "int",
+		Verify:          true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestAccumulatorMissing(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"Composite", `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int, acc *int)
+}
+`, "composite method FeedTo should take the consumer and a *int accumulator"},
+		{"Consumer", `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer, acc *int)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`, "consumer method Lit should take a *int accumulator as its last argument"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, tt.src)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.AccumulatorType = "int"
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceiverName(t *testing.T) {
+	const src = `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Neg(Of Expr)
+}
+`
+	for _, tt := range []struct {
+		name, receiver, want string
+	}{
+		{"Default", "", "func (e *Lit) FeedTo("},
+		{"Override", "self", "func (self *Lit) FeedTo("},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, src)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.ReceiverName = tt.receiver
+			config.GenerateEqual = true
+			config.GenerateString = true
+			config.Verify = true
+
+			out, err := config.GenerateBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(out, []byte(tt.want)) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestReceiverNameAvoidsCollisions(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+
+// Would be shadowed by a receiver named e.
+type e int
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Verify = true
+
+	out, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "func (e_ *Lit) FeedTo("
+	if !bytes.Contains(out, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, out)
+	}
+}
+
+func TestReceiverNameOverrideCollides(t *testing.T) {
+	for _, receiver := range []string{"N", "consumer", "Expr"} {
+		config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+		config.TypeNames.Composite = "Expr"
+		config.TypeNames.Consumer = "ExprConsumer"
+		config.ReceiverName = receiver
+
+		_, err := config.GenerateBytes()
+		want := "receiver name " + receiver + " collides"
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got error %v, want one containing %q", err, want)
+		}
+	}
+}
+
+func TestVariantTypesAreFollowedByTheirMethods(t *testing.T) {
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName:  "intexpr",
+		Constructors: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var variants int
+	for i, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		variants++
+		name := gen.Specs[0].(*ast.TypeSpec).Name.Name
+
+		if i+1 == len(f.Decls) {
+			t.Errorf("type %s is the last declaration", name)
+			continue
+		}
+		fun, ok := f.Decls[i+1].(*ast.FuncDecl)
+		if !ok || fun.Recv == nil || types.ExprString(fun.Recv.List[0].Type) != "*"+name {
+			t.Errorf("type %s is not followed by its method", name)
+		}
+	}
+	if variants == 0 {
+		t.Errorf("no variant types in the output:\n%s", src)
+	}
+}
+
+func TestValueReceiver(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/value/ref.go")
+
+	config := Config{
+		Directory:            filepath.FromSlash("internal/test_cases/value"),
+		PackageName:          "value",
+		ValueReceiver:        true,
+		EmitAssertions:       true,
+		Constructors:         true,
+		ConcreteConstructors: true,
+		GenerateEqual:        true,
+		GenerateString:       true,
+		GenerateKind:         true,
+		GenerateJSON:         true,
+		Sealed:               true,
+		Verify:               true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestLogf(t *testing.T) {
+	var lines []string
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+		Verify:      true,
+		Logf: func(format string, args ...interface{}) {
+			lines = append(lines, fmt.Sprintf(format, args...))
+		},
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log := strings.Join(lines, "\n")
+	for _, want := range []string{
+		"parsed package intexpr",
+		"found composite Expr at ",
+		"generating variant Lit of Expr",
+		"generating variant Mul of Expr",
+		"verifying the generated code",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log does not contain %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/copy/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/copy"),
+		PackageName:   "copy",
+		GenerateCopy:  true,
+		GenerateEqual: true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestQualifiedArgumentTypes(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+import "time"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Stamp(When time.Time)
+	Span(From, To time.Time, Step *time.Duration)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Constructors = true
+	config.GenerateMatch = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"type Stamp struct {\n\tWhen time.Time\n}",
+		"\tFrom, To time.Time\n\tStep     *time.Duration\n",
+		"{ consumer.Stamp(e.When) }",
+		"{ consumer.Span(e.From, e.To, e.Step) }",
+		"func MakeStamp(when time.Time) Expr",
+		"onSpan func(from, to time.Time, step *time.Duration)",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestQualifiedArgumentTypesNeedExportedNames(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+import "time"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Stamp(when time.Time)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	want := "consumer method Stamp has argument names that can't be turned into exported field names"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestDefaultOutputName(t *testing.T) {
+	var config Config
+	if got := config.DefaultOutputName(); got != "" {
+		t.Errorf("got %q without pairs, want none", got)
+	}
+
+	config.TypeNames = TypeNames{Composite: "Expr", Consumer: "ExprConsumer"}
+	config.Pairs = []TypeNames{{Composite: "Pattern", Consumer: "PatternConsumer"}}
+	if got, want := config.DefaultOutputName(), "expr_impl.go"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	config.TypeNames = TypeNames{}
+	if got, want := config.DefaultOutputName(), "pattern_impl.go"; got != want {
+		t.Errorf("got %q with only more pairs, want %q", got, want)
+	}
+}
+
+func TestPerComposite(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/prefix"),
+		PackageName: "prefix",
+	}
+	config.TypeNames = TypeNames{Composite: "Expr", Consumer: "ExprConsumer", VariantPrefix: "Expr"}
+	config.Pairs = []TypeNames{{Composite: "Pattern", Consumer: "PatternConsumer", VariantSuffix: "Pattern"}}
+
+	configs := config.PerComposite()
+	if len(configs) != 2 {
+		t.Fatalf("got %d configs, want 2", len(configs))
+	}
+
+	for i, want := range []string{"expr_impl.go", "pattern_impl.go"} {
+		single := configs[i]
+		if got := OutputFileName(single.TypeNames.Composite); got != want {
+			t.Errorf("config %d: got file name %q, want %q", i, got, want)
+		}
+		if len(single.Pairs) != 0 {
+			t.Errorf("config %d: got %d more pairs, want none", i, len(single.Pairs))
+		}
+
+		_, err := single.GenerateBytes()
+		if err != nil {
+			t.Errorf("config %d: %s", i, err)
+		}
+	}
+}
+
+func TestSwappedArguments(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+`)
+	config.TypeNames.Composite = "ExprConsumer"
+	config.TypeNames.Consumer = "Expr"
+
+	_, err := config.GenerateBytes()
+	want := "are the arguments swapped? Expr looks like the composite and ExprConsumer like the consumer"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+	if !errors.Is(err, ErrMethodCount) {
+		t.Errorf("got error %v, want one matching ErrMethodCount", err)
+	}
+}
+
+func TestConsumerByPointer(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/pointer/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/pointer"),
+		PackageName:   "pointer",
+		GenerateMatch: true,
+		GenerateFold:  true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestImportedConsumerByPointer(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/imported/pointer/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/imported/pointer"),
+		PackageName:   "pointer",
+		GenerateMatch: true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "shared.ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestAliasedConsumer(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/imported/aliased/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/imported/aliased"),
+		PackageName:   "aliased",
+		GenerateMatch: true,
+		GenerateBase:  true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestLocallyAliasedConsumer(t *testing.T) {
+	config := configFromSource(t, `package alias
+
+type Expr interface {
+	FeedTo(cons Consumer)
+}
+
+type Consumer = exprConsumer
+
+type exprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "Consumer"
+	config.GenerateBase = true
+
+	out, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"func (e *Lit) FeedTo(consumer Consumer)", "func (BaseConsumer) Lit(N int) {}"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/walk/ref.go")
+
+	config := Config{
+		Directory:    filepath.FromSlash("internal/test_cases/walk"),
+		PackageName:  "walk",
+		GenerateWalk: true,
+		Verify:       true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestWalkParent(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/walkparent/ref.go")
+
+	config := Config{
+		Directory:          filepath.FromSlash("internal/test_cases/walkparent"),
+		PackageName:        "walkparent",
+		GenerateWalkParent: true,
+		Verify:             true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestGenericWalk(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr[T any] interface {
+	FeedTo(cons ExprConsumer[T])
+}
+
+type ExprConsumer[T any] interface {
+	Lit(Value T)
+	Pair(Left, Right Expr[T])
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateWalk = true
+	config.GenerateWalkParent = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "func WalkExpr[T any](e Expr[T], pre func(Expr[T]) bool) {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestPassthrough(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/passthrough/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/passthrough"),
+		PackageName: "passthrough",
+		Verify:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestPassthroughMismatch(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"Composite", `package expr
+
+import "context"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+//irgen:passthrough Ctx
+type ExprConsumer interface {
+	Lit(Ctx context.Context, N int)
+}
+`, "composite method FeedTo should take the Ctx passthrough arguments followed by the consumer"},
+		{"Consumer", `package expr
+
+import "context"
+
+type Expr interface {
+	FeedTo(ctx context.Context, cons ExprConsumer)
+}
+
+//irgen:passthrough Ctx
+type ExprConsumer interface {
+	Lit(N int)
+}
+`, "consumer method Lit should take Ctx context.Context as its argument number 1"},
+		{"Type", `package expr
+
+import "context"
+
+type Expr interface {
+	FeedTo(ctx context.Context, cons ExprConsumer)
+}
+
+//irgen:passthrough Ctx
+type ExprConsumer interface {
+	Lit(Ctx string, N int)
+}
+`, "consumer method Lit should take Ctx context.Context as its argument number 1"},
+		{"Collision", `package expr
+
+type Expr interface {
+	FeedTo(consumer int, cons ExprConsumer)
+}
+
+//irgen:passthrough Consumer
+type ExprConsumer interface {
+	Lit(Consumer int, N int)
+}
+`, "passthrough parameter Consumer would collide with the consumer parameter"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, tt.src)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineDirectives(t *testing.T) {
+	config := Config{
+		Directory:      filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName:    "intexpr",
+		LineDirectives: true,
+		OutputFile:     "ref.go",
+		Verify:         true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "/*line expr.go:14*/ N int"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Fatalf("output does not contain %q:\n%s", want, src)
+	}
+
+	// NOTE: The parser applies line directives, just like the compiler.
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "ref.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			spec := decl.Specs[0].(*ast.TypeSpec)
+			field := spec.Type.(*ast.StructType).Fields.List[0]
+			if pos := fset.Position(field.Pos()); pos.Filename != "expr.go" {
+				t.Errorf("field of %s is at %s, want it in expr.go", spec.Name.Name, pos)
+			}
+		case *ast.FuncDecl:
+			pos := fset.Position(decl.Pos())
+			if pos.Filename != "ref.go" || pos.Line != fset.PositionFor(decl.Pos(), false).Line {
+				t.Errorf("method of %s is at %s, want it where it is in ref.go", types.ExprString(decl.Recv.List[0].Type), pos)
+			}
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/rewrite/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/rewrite"),
+		PackageName: "rewrite",
+		GenerateMap: true,
+		Verify:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestGenericValueReceiverMap(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr[T any] interface {
+	FeedTo(cons ExprConsumer[T])
+}
+
+type ExprConsumer[T any] interface {
+	Lit(Value T)
+	Pair(Left, Right Expr[T])
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateMap = true
+	config.ValueReceiver = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func MapExpr[T any](e Expr[T], f func(Expr[T]) Expr[T]) Expr[T] {",
+		"e = Pair[T]{Left: MapExpr(v.Left, f), Right: MapExpr(v.Right, f)}",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestMixedParameterGroups(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Range(Lo, Hi int, Step Expr, Name, Label string)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Constructors = true
+	config.GenerateMatch = true
+	config.GenerateEqual = true
+	config.GenerateCopy = true
+	config.GenerateString = true
+	config.GenerateMap = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"type Range struct {\n\tLo, Hi      int\n\tStep        Expr\n\tName, Label string\n}",
+		"consumer.Range(e.Lo, e.Hi, e.Step, e.Name, e.Label)",
+		"func MakeRange(lo, hi int, step Expr, name, label string) Expr {",
+		"return &Range{Lo: lo, Hi: hi, Step: step, Name: name, Label: label}",
+		"onRange func(lo, hi int, step Expr, name, label string)",
+		"e = &Range{Lo: v.Lo, Hi: v.Hi, Step: MapExpr(v.Step, f), Name: v.Name, Label: v.Label}",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestIsUpToDate(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	current, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		existing []byte
+		want     bool
+	}{
+		{"Current", current, true},
+		{"CRLF", bytes.ReplaceAll(current, []byte("\n"), []byte("\r\n")), true},
+		{"Stale", bytes.Replace(current, []byte("N int"), []byte("N int64"), 1), false},
+		{"Missing", nil, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.IsUpToDate(tt.existing)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapFields(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/mapfields/ref.go")
+
+	config := Config{
+		Directory:      filepath.FromSlash("internal/test_cases/mapfields"),
+		PackageName:    "mapfields",
+		GenerateEqual:  true,
+		GenerateCopy:   true,
+		GenerateString: true,
+		GenerateWalk:   true,
+		GenerateMap:    true,
+		GenerateJSON:   true,
+		Verify:         true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestNoVariants(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface{}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.EmitAssertions = true
+	config.Constructors = true
+	config.GenerateMatch = true
+	config.GenerateBase = true
+	config.GenerateEqual = true
+	config.GenerateCopy = true
+	config.GenerateString = true
+	config.GenerateFold = true
+	config.GenerateWalk = true
+	config.GenerateMap = true
+	config.GenerateKind = true
+	config.GenerateJSON = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "// Code generated by irgen; DO NOT EDIT.\n\npackage expr\n"
+	if string(src) != want {
+		t.Errorf("got\n%s\nwant\n%s", src, want)
+	}
+
+	var summary bytes.Buffer
+	config.DryRun, config.Summary = true, &summary
+	_, err = config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := summary.String(), "Expr/ExprConsumer: no variants (FeedTo)\n"; got != want {
+		t.Errorf("got summary %q, want %q", got, want)
+	}
+}
+
+func TestVariants(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	got, err := config.Variants()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Lit", "Var", "Add", "Sub", "Mul"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got variants %q, want %q", got, want)
+	}
+}
+
+func TestVariantsWithPrefix(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/prefix"),
+		PackageName: "prefix",
+	}
+	config.TypeNames.Composite = "Pattern"
+	config.TypeNames.Consumer = "PatternConsumer"
+	config.TypeNames.VariantSuffix = "Pattern"
+
+	got, err := config.Variants()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) == 0 {
+		t.Fatal("got no variants")
+	}
+	for _, name := range got {
+		if !strings.HasSuffix(name, "Pattern") {
+			t.Errorf("variant %s does not have the Pattern suffix", name)
+		}
+	}
+}
+
+func TestMissingMethods(t *testing.T) {
+	config := configFromSource(t, `package partial
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Var(Name string)
+	Add(L, R Expr)
+	Mul(L, R Expr)
+}
+
+type printer struct{}
+
+func (p printer) Lit(N int)     {}
+func (p *printer) Add(L, R Expr) {}
+func (p printer) String() string { return "" }
+
+type evaluator struct{}
+
+func (evaluator) Var(Name string) {}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	got, err := config.MissingMethods("printer")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Var", "Mul"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got missing methods %q, want %q", got, want)
+	}
+}
+
+func TestMissingMethodsOfUndeclaredType(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName: "intexpr",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.MissingMethods("evaluator")
+	want := "can't retrieve consumer implementation type evaluator spec"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestFallibleVisitor(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/fallible/ref.go")
+
+	config := Config{
+		Directory:       filepath.FromSlash("internal/test_cases/fallible"),
+		PackageName:     "fallible",
+		FallibleVisitor: true,
+		Verify:          true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestFallibleVisitorResults(t *testing.T) {
+	for _, tt := range []struct {
+		name, src, want string
+	}{
+		{"NoResult", `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`, "composite method FeedTo should return error, since the visitor is fallible"},
+		{"OtherResult", `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) int
+}
+
+type ExprConsumer interface {
+	Lit(N int) int
+}
+`, "composite method FeedTo should return error, since the visitor is fallible"},
+		{"Consumer", `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer) error
+}
+
+type ExprConsumer interface {
+	Lit(N int) int
+}
+`, "consumer method Lit returns int (should return error, like composite method FeedTo)"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, tt.src)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.FallibleVisitor = true
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVariantManifest(t *testing.T) {
+	config := Config{
+		Directory:           filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName:         "intexpr",
+		EmitVariantManifest: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "//irgen:variants Lit Var Add Sub Mul\ntype Lit struct {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("the output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestVariantManifestFollowsDocComment(t *testing.T) {
+	config := Config{
+		Directory:           filepath.FromSlash("internal/test_cases/docs"),
+		PackageName:         "docs",
+		EmitVariantManifest: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "// Lit is an integer literal.\n//\n//irgen:variants Lit Var Add\ntype Lit struct {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("the output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestVariantManifestIsAKnownDirective(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.EmitVariantManifest = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(config.Directory, "expr_impl.go"), src, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: Regenerating parses the previous output along with the source.
+	_, err = config.GenerateBytes()
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// Go has no type parameters on methods, so a composite method can't be generic
+// over the consumer. The parser reports that before irgen sees the method.
+func TestTypeParameterizedMethod(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type ExprConstraint interface {
+	ExprConsumer
+}
+
+type Expr interface {
+	FeedTo[C ExprConstraint](c C)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err == nil || !strings.Contains(err.Error(), "must have no type parameters") {
+		t.Errorf("got error %v, want one about the type parameters", err)
+	}
+}
+
+func TestPositionalNames(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Pair(int, string)
+	Ignore(_ int, Name string, _ bool)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateEqual = true
+	config.GenerateString = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"type Pair struct {\n\tArg0 int\n\tArg1 string\n}",
+		"consumer.Pair(e.Arg0, e.Arg1)",
+		"type Ignore struct {\n\tArg0 int\n\tName string\n\tArg2 bool\n}",
+		"consumer.Ignore(e.Arg0, e.Name, e.Arg2)",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestPositionalNamesMatchAndFold(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/positional/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/positional"),
+		PackageName:   "positional",
+		GenerateMatch: true,
+		GenerateFold:  true,
+		GenerateBase:  true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestPositionalNamesCollide(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Ignore(_ int, Arg0 string)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	want := "consumer method Ignore has an argument named Arg0, which its argument number 1 would be named too"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestStrictNames(t *testing.T) {
+	for _, tt := range []struct {
+		name, method, want string
+	}{
+		{"Unnamed", "Pair(int, string)", "consumer method Pair has unnamed arguments"},
+		{"Blank", "Ignore(_ int)", "consumer method Ignore has argument names that can't be turned into exported field names"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	`+tt.method+`
+}
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.StrictNames = true
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatingConstructors(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/validating/ref.go")
+
+	config := Config{
+		Directory:              filepath.FromSlash("internal/test_cases/validating"),
+		PackageName:            "validating",
+		ValidatingConstructors: true,
+		Verify:                 true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestValidatingConstructorsAvoidParamNames(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Pair(V, Err int)
+}
+
+func validatePair(p *Pair) error { return nil }
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.ValidatingConstructors = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "if err_ := validatePair(v_); err_ != nil {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestValidatingAndConcreteConstructors(t *testing.T) {
+	config := Config{
+		Directory:              filepath.FromSlash("internal/test_cases/intexpr"),
+		PackageName:            "intexpr",
+		ConcreteConstructors:   true,
+		ValidatingConstructors: true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	err := config.Validate()
+	want := "concrete and validating constructors can't both be generated"
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got error %v, want one containing %q", err, want)
+	}
+}
+
+func TestCompositeImportPath(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/split/variants/ref.go")
+
+	config := Config{
+		Directory:           filepath.FromSlash("internal/test_cases/split/expr"),
+		PackageName:         "expr",
+		OutputPackageName:   "variants",
+		CompositeImportPath: "github.com/szabba/irgen/internal/test_cases/split/expr",
+		EmitAssertions:      true,
+		Verify:              true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestCompositeImportPathWithoutModule(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Neg(Of Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.CompositeImportPath = "example.com/ast/expr"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`import "example.com/ast/expr"`,
+		"Of expr.Expr",
+		"func (e *Neg) FeedTo(consumer expr.ExprConsumer)",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+// largeConsumerConfig writes a package with a consumer of n methods, like one
+// for a big AST, and returns a config generating its variants.
+func largeConsumerConfig(b *testing.B, n int) Config {
+	b.Helper()
+
+	var src strings.Builder
+	src.WriteString("package expr\n\ntype Expr interface {\n\tFeedTo(cons ExprConsumer)\n}\n\ntype ExprConsumer interface {\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&src, "\tNode%d(Name string, Pos int, Children []Expr, Parent Expr)\n", i)
+	}
+	src.WriteString("}\n")
+
+	dir := b.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "expr.go"), []byte(src.String()), 0644)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	config := Config{Directory: dir, PackageName: "expr"}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	return config
+}
+
+// Generating is dominated by printing and formatting the output. Preallocating
+// the declarations and the output buffer, and comparing field types with the
+// composite without rendering them, took these from about
+//
+//	BenchmarkGenerateLargeConsumer              9.9 ms/op   2.41 MB/op   54024 allocs/op
+//	BenchmarkGenerateLargeConsumerAllFeatures   101 ms/op   24.5 MB/op  494966 allocs/op
+//
+// to
+//
+//	BenchmarkGenerateLargeConsumer              7.7 ms/op   2.40 MB/op   53983 allocs/op
+//	BenchmarkGenerateLargeConsumerAllFeatures    97 ms/op   22.9 MB/op  466927 allocs/op
+//
+// with the output staying the same.
+func BenchmarkGenerateLargeConsumer(b *testing.B) {
+	config := largeConsumerConfig(b, 200)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := config.GenerateBytes()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGenerateLargeConsumerAllFeatures(b *testing.B) {
+	config := largeConsumerConfig(b, 200)
+	config.EmitAssertions = true
+	config.Constructors = true
+	config.GenerateMatch = true
+	config.GenerateBase = true
+	config.GenerateEqual = true
+	config.GenerateCopy = true
+	config.GenerateString = true
+	config.GenerateWalk = true
+	config.GenerateMap = true
+	config.GenerateKind = true
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := config.GenerateBytes()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestAllConsumerMethodErrorsReported(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+import "time"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Stamp(when time.Time)
+	Add(Left, Right Expr) int
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	for _, want := range []string{
+		"consumer method Stamp has argument names that can't be turned into exported field names",
+		"consumer method Add has 1 results (should have 0, like composite method FeedTo)",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestSeq(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/seq/ref.go")
+
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/seq"),
+		PackageName: "seq",
+		GenerateSeq: true,
+		Verify:      true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestHash(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/hash/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/hash"),
+		PackageName:   "hash",
+		GenerateEqual: true,
+		GenerateHash:  true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestGenericHash(t *testing.T) {
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/generic"),
+		PackageName:   "generic",
+		Constructors:  true,
+		GenerateMatch: true,
+		GenerateHash:  true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Tree"
+	config.TypeNames.Consumer = "TreeConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "func hashTree[T any](e Tree[T]) uint64 {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestGenericEqual(t *testing.T) {
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/generic"),
+		PackageName:   "generic",
+		Constructors:  true,
+		GenerateMatch: true,
+		GenerateEqual: true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Tree"
+	config.TypeNames.Consumer = "TreeConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "if !reflect.DeepEqual(t.Value, that.Value) {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestComparableTypeParamEqual(t *testing.T) {
+	config := configFromSource(t, `package set
+
+type Key interface {
+	comparable
+}
+
+type Set[K Key, V any] interface {
+	FeedTo(cons SetConsumer[K, V])
+}
+
+type SetConsumer[K Key, V any] interface {
+	Entry(Key K, Value V)
+}
+`)
+	config.TypeNames.Composite = "Set"
+	config.TypeNames.Consumer = "SetConsumer"
+	config.GenerateEqual = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"if s.Key != that.Key {", "if !reflect.DeepEqual(s.Value, that.Value) {"} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenericSeq(t *testing.T) {
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/generic"),
+		PackageName:   "generic",
+		Constructors:  true,
+		GenerateMatch: true,
+		GenerateSeq:   true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Tree"
+	config.TypeNames.Consumer = "TreeConsumer"
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "func TreeAll[T any](e Tree[T]) iter.Seq[Tree[T]] {"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestOpaqueFields(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/opaque/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/opaque"),
+		PackageName:   "opaque",
+		GenerateEqual: true,
+		GenerateWalk:  true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestPointerFields(t *testing.T) {
+	reference := filepath.FromSlash("./internal/test_cases/pointerfields/ref.go")
+
+	config := Config{
+		Directory:     filepath.FromSlash("internal/test_cases/pointerfields"),
+		PackageName:   "pointerfields",
+		GenerateEqual: true,
+		GenerateCopy:  true,
+		GenerateWalk:  true,
+		GenerateSeq:   true,
+		Verify:        true,
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	config.compareOuputToReferenceFile(t, reference)
+}
+
+func TestFieldGroups(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		expand bool
+		want   []string
+	}{
+		{"Grouped", false, []string{
+			"type Named struct {\n\tName string\n\tArgs []Type\n}",
+			"type Function struct {\n\tArg, Output Type\n}",
+		}},
+		{"Expanded", true, []string{
+			"type Named struct {\n\tName string\n\tArgs []Type\n}",
+			"type Function struct {\n\tArg    Type\n\tOutput Type\n}",
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				Directory:    filepath.FromSlash("internal/test_cases/types"),
+				PackageName:  "types",
+				ExpandGroups: tt.expand,
+			}
+			config.TypeNames.Composite = "Type"
+			config.TypeNames.Consumer = "TypeConsumer"
+
+			src, err := config.GenerateBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range tt.want {
+				if !bytes.Contains(src, []byte(want)) {
+					t.Errorf("output does not contain %q:\n%s", want, src)
+				}
+			}
+
+			formatted, err := format.Source(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(formatted, src) {
+				t.Errorf("gofmt changes the output:\n%s", lineDiff(string(src), string(formatted)))
+			}
+		})
+	}
+}
+
+func TestLicenseHeader(t *testing.T) {
+	for _, tt := range []struct {
+		license, want string
+	}{
+		{"mpl2", `// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+`},
+		{"apache2", `// Licensed under the Apache License, Version 2.0 (the "License");`},
+		{"MIT OR Apache-2.0", "// SPDX-License-Identifier: MIT OR Apache-2.0\n\n"},
+	} {
+		t.Run(tt.license, func(t *testing.T) {
+			config := Config{
+				Directory:     filepath.FromSlash("internal/test_cases/intexpr"),
+				PackageName:   "intexpr",
+				LicenseHeader: tt.license,
+				Header:        "Regenerate with go generate.",
+				BuildTags:     []string{"linux"},
+			}
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+
+			src, err := config.GenerateBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.HasPrefix(src, []byte(tt.want)) {
+				t.Errorf("output does not start with %q:\n%s", tt.want, src)
+			}
+
+			// NOTE: The license goes first, and the build constraint still
+			// has to come before the package clause to count.
+			order := []string{"// Regenerate with go generate.", "// Code generated by irgen; DO NOT EDIT.", "//go:build linux", "package intexpr"}
+			last := 0
+			for _, line := range order {
+				i := bytes.Index(src, []byte(line))
+				if i < last {
+					t.Errorf("%q is out of place:\n%s", line, src)
+				}
+				last = i
+			}
+
+			f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Doc != nil {
+				t.Errorf("the header became the package doc comment %q", f.Doc.Text())
+			}
+		})
+	}
+}
+
+func TestConstraintConsumer(t *testing.T) {
+	for _, tt := range []struct {
+		name, elements, want string
+	}{
+		{"Union", "~int | ~string", "interface ExprConsumer has the type element ~int | ~string, which makes it a constraint"},
+		{"Tilde", "Lit(N int)\n\t~int", "interface ExprConsumer has the type element ~int, which makes it a constraint"},
+		{"Predeclared", "comparable", "interface ExprConsumer has the type element comparable, which makes it a constraint"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	`+tt.elements+`
+}
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldRenames(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+
+	// Add is a sum.
+	//
+	//irgen:field Left L
+	//irgen:tag Left json:"left"
+	Add(Left, Right Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Constructors = true
+	config.GenerateEqual = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"L     Expr `json:\"left\"`\n\tRight Expr\n",
+		"consumer.Add(e.L, e.Right)",
+		"return &Add{L: l, Right: right}",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestBadFieldRenames(t *testing.T) {
+	for _, tt := range []struct {
+		name, directive, want string
+	}{
+		{"NoParameter", "//irgen:field Middle M", `consumer method Add has no parameter "Middle" to rename`},
+		{"Unexported", "//irgen:field Left l", `field name "l" for parameter Left of consumer method Add is not an exported identifier`},
+		{"Twice", "//irgen:field Left L\n\t//irgen:field Left M", "parameter Left of consumer method Add is renamed more than once"},
+		{"Collision", "//irgen:field Left Right", "parameters Left and Right of consumer method Add would both become field Right"},
+		{"Arguments", "//irgen:field Left", "//irgen:field should name a parameter and the field name for it"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	`+tt.directive+`
+	Add(Left, Right Expr)
+}
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompositeAlias(t *testing.T) {
+	for _, tt := range []struct {
+		name, decl string
+	}{
+		{"Alias", "type Expr = exprIface"},
+		{"Defined", "type Expr exprIface"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, `package expr
+
+`+tt.decl+`
+
+type exprIface interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.Constructors = true
+			config.GenerateEqual = true
+			config.Verify = true
+
+			src, err := config.GenerateBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range []string{
+				"func (e *Add) FeedTo(consumer ExprConsumer) { consumer.Add(e.Left, e.Right) }",
+				"func MakeLit(n int) Expr {",
+			} {
+				if !bytes.Contains(src, []byte(want)) {
+					t.Errorf("output does not contain %q:\n%s", want, src)
+				}
+			}
+		})
+	}
+}
+
+func TestCompositeAliasOfNonInterface(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr = exprStruct
+
+type exprStruct struct{}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	_, err := config.GenerateBytes()
+	if !errors.Is(err, ErrNotInterface) {
+		t.Errorf("got error %v, want one matching ErrNotInterface", err)
+	}
+}
+
+func TestPostProcess(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.Verify = true
+	config.PostProcess = func(file *ast.File) error {
+		decl, err := parser.ParseFile(token.NewFileSet(), "", "package expr\n\nvar _ = &Lit{N: 1}\n", 0)
+		if err != nil {
+			return err
+		}
+		file.Decls = append(file.Decls, decl.Decls...)
+		return nil
+	}
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "var _ = &Lit{N: 1}"
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestPostProcessError(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	failure := errors.New("no registry")
+	config.PostProcess = func(*ast.File) error { return failure }
+
+	_, err := config.GenerateBytes()
+	if !errors.Is(err, failure) {
+		t.Errorf("got error %v, want one matching %v", err, failure)
+	}
+}
+
+func TestLintSuppressions(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	// Lit is a literal.
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.GenerateBase = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(src, []byte("nolint")) {
+		t.Errorf("output suppresses linters without being asked to:\n%s", src)
+	}
+
+	config.LintSuppressions = []string{"gocritic", "unused"}
+	src, err = config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"// Lit is a literal.\n//\n//nolint:gocritic,unused\ntype Lit struct {",
+		"//nolint:gocritic,unused\nfunc (e *Lit) FeedTo(consumer ExprConsumer) { consumer.Lit(e.N) }",
+		"//nolint:gocritic,unused\ntype BaseExprConsumer struct {",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerateContextCanceled(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/equal"),
+		PackageName: "equal",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	err := config.GenerateContext(ctx, &out)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want one matching context.Canceled", err)
+	}
+	if out.Len() > 0 {
+		t.Errorf("got output after the context was canceled:\n%s", out.Bytes())
+	}
+}
+
+func TestGenerateContext(t *testing.T) {
+	config := Config{
+		Directory:   filepath.FromSlash("internal/test_cases/equal"),
+		PackageName: "equal",
+	}
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+
+	var withContext, without bytes.Buffer
+	err := config.GenerateContext(context.Background(), &withContext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = config.Generate(&without)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(withContext.Bytes(), without.Bytes()) {
+		t.Errorf("got different output with a context:\n%s", lineDiff(without.String(), withContext.String()))
+	}
+}
+
+func TestTypesOnly(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+import "time"
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Sleep(For time.Duration, Then Expr)
+}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.TypesOnly = true
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			t.Errorf("output declares function %s:\n%s", decl.Name.Name, src)
+		case *ast.GenDecl:
+			if decl.Tok == token.TYPE {
+				types = append(types, decl.Specs[0].(*ast.TypeSpec).Name.Name)
+			}
+		}
+	}
+	if fmt.Sprint(types) != "[Lit Sleep]" {
+		t.Errorf("got types %v, want [Lit Sleep]", types)
+	}
+	if !bytes.Contains(src, []byte(`import "time"`)) {
+		t.Errorf("output does not import the package of a field type:\n%s", src)
+	}
+}
+
+func TestMethodBodyTemplate(t *testing.T) {
+	config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+	Add(Left, Right Expr)
+}
+
+func trace(variant string, fields ...interface{}) {}
+`)
+	config.TypeNames.Composite = "Expr"
+	config.TypeNames.Consumer = "ExprConsumer"
+	config.MethodBodyTemplate = `trace("{{.Method}} {{.Variant}}"{{range .Fields}}, {{$.Receiver}}.{{.}}{{end}})
+{{if .Returns}}return {{end}}{{.Call}}`
+	config.Verify = true
+
+	src, err := config.GenerateBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `func (e *Add) FeedTo(consumer ExprConsumer) {
+	trace("FeedTo Add", e.Left, e.Right)
+	consumer.Add(e.Left, e.Right)
+}`
+	if !bytes.Contains(src, []byte(want)) {
+		t.Errorf("output does not contain %q:\n%s", want, src)
+	}
+}
+
+func TestBadMethodBodyTemplate(t *testing.T) {
+	for _, tt := range []struct {
+		name, template, want string
+	}{
+		{"Unparsable", "{{.Call", "method body template can't be parsed"},
+		{"UnknownField", "{{.Callee}}", "can't execute the method body template for variant Lit"},
+		{"InvalidBody", "{{.Call}} +", "the method body template gives variant Lit an invalid body"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := configFromSource(t, `package expr
+
+type Expr interface {
+	FeedTo(cons ExprConsumer)
+}
+
+type ExprConsumer interface {
+	Lit(N int)
+}
+`)
+			config.TypeNames.Composite = "Expr"
+			config.TypeNames.Consumer = "ExprConsumer"
+			config.MethodBodyTemplate = tt.template
+
+			_, err := config.GenerateBytes()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
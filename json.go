@@ -0,0 +1,206 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// generateJSON builds a MarshalJSON method for each variant and a wrapper type
+// that can be unmarshalled into any of them. The JSON of a variant is an
+// object with its fields, plus a "type" field naming the variant, eg.
+//
+//	{"type": "Add", "Left": {"type": "Lit", "N": 1}, "Right": {"type": "Var", "Name": "x"}}
+//
+// Unmarshalling fields of the composite type (or slices, maps or pointers of
+// it) goes through the wrapper, recursively. No field can be marshalled under a key
+// clashing with "type".
+func (gen *generator) generateJSON(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	wrapperName := gen.composite.Name.Name + "JSON"
+	wrapper := types.ExprString(gen.instantiate(wrapperName))
+	recv := gen.receiverName()
+	marshal := gen.qualified("encoding/json", "Marshal")
+	unmarshal := gen.qualified("encoding/json", "Unmarshal")
+
+	sliceHelper := "unwrap" + gen.composite.Name.Name + "s"
+	mapHelper := "unwrap" + gen.composite.Name.Name + "Map"
+	ptrHelper := "unwrap" + gen.composite.Name.Name + "Ptr"
+	ptrSliceHelper := "unwrap" + gen.composite.Name.Name + "Ptrs"
+
+	var (
+		src                                       strings.Builder
+		needsSlice, needsMap, needsPtr, needsPtrs bool
+	)
+
+	for i, variant := range variants {
+		err := gen.checkJSONKeys(variant, gen.variants[i])
+		if err != nil {
+			return nil, err
+		}
+
+		fields := []string{gen.jsonDiscriminator(variant) + " string `json:\"type\"`"}
+		values := []string{fmt.Sprintf("%q", variant.Name.Name)}
+		for _, field := range gen.variantFields(variant) {
+			fields = append(fields, jsonField(field.Name, types.ExprString(field.Type), field.Tag))
+			values = append(values, recv+"."+field.Name)
+		}
+
+		fmt.Fprintf(&src, "func (%s %s) MarshalJSON() ([]byte, error) {\n", recv, gen.variantRecv(variant))
+		fmt.Fprintf(&src, "return %s(struct {\n%s\n}{%s})\n}\n\n", marshal, strings.Join(fields, "\n"), strings.Join(values, ", "))
+	}
+
+	fmt.Fprintf(&src, "type %s%s struct {\n%s %s\n}\n\n", wrapperName, gen.typeParamsDecl(), gen.composite.Name.Name, composite)
+
+	fmt.Fprintf(&src, "func (w %s) MarshalJSON() ([]byte, error) {\n", wrapper)
+	fmt.Fprintf(&src, "return %s(w.%s)\n}\n\n", marshal, gen.composite.Name.Name)
+
+	fmt.Fprintf(&src, "func (w *%s) UnmarshalJSON(data []byte) error {\n", wrapper)
+	fmt.Fprintf(&src, "if string(data) == \"null\" {\nw.%s = nil\nreturn nil\n}\n\n", gen.composite.Name.Name)
+	fmt.Fprintf(&src, "var tagged struct {\nType string `json:\"type\"`\n}\n")
+	fmt.Fprintf(&src, "err := %s(data, &tagged)\nif err != nil {\nreturn err\n}\n\n", unmarshal)
+	fmt.Fprintf(&src, "switch tagged.Type {\n")
+
+	for _, variant := range variants {
+		var (
+			fields []string
+			elts   []string
+		)
+		for _, field := range gen.variantFields(variant) {
+			typ := types.ExprString(field.Type)
+			value := "v." + field.Name
+
+			switch field.Kind {
+			case compositeField:
+				typ = wrapper
+				value += "." + gen.composite.Name.Name
+			case compositeSliceField:
+				needsSlice = true
+				typ = "[]" + wrapper
+				value = sliceHelper + "(" + value + ")"
+			case compositeMapField:
+				needsMap = true
+				typ = "map[" + types.ExprString(field.Type.(*ast.MapType).Key) + "]" + wrapper
+				value = mapHelper + "(" + value + ")"
+			case compositePointerField:
+				needsPtr = true
+				typ = "*" + wrapper
+				value = ptrHelper + "(" + value + ")"
+			case compositePointerSliceField:
+				needsPtr, needsPtrs = true, true
+				typ = "[]*" + wrapper
+				value = ptrSliceHelper + "(" + value + ")"
+			}
+
+			fields = append(fields, jsonField(field.Name, typ, field.Tag))
+			elts = append(elts, field.Name+": "+value)
+		}
+
+		fmt.Fprintf(&src, "case %q:\n", variant.Name.Name)
+		if len(fields) > 0 {
+			fmt.Fprintf(&src, "var v struct {\n%s\n}\n", strings.Join(fields, "\n"))
+			fmt.Fprintf(&src, "err := %s(data, &v)\nif err != nil {\nreturn err\n}\n", unmarshal)
+		}
+		lit := fmt.Sprintf("%s{%s}", gen.variantType(variant), strings.Join(elts, ", "))
+		fmt.Fprintf(&src, "w.%s = %s\n", gen.composite.Name.Name, gen.variantValue(lit))
+	}
+
+	fmt.Fprintf(&src, "default:\nreturn %s(\"unknown %s variant %%q\", tagged.Type)\n}\n",
+		gen.qualified("fmt", "Errorf"), gen.composite.Name.Name)
+	fmt.Fprintf(&src, "return nil\n}\n\n")
+
+	if needsSlice {
+		fmt.Fprintf(&src, "func %s%s(ws []%s) []%s {\n", sliceHelper, gen.typeParamsDecl(), wrapper, composite)
+		fmt.Fprintf(&src, "if ws == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "es := make([]%s, len(ws))\n", composite)
+		fmt.Fprintf(&src, "for i, w := range ws {\nes[i] = w.%s\n}\n", gen.composite.Name.Name)
+		fmt.Fprintf(&src, "return es\n}\n\n")
+	}
+
+	if needsPtr {
+		fmt.Fprintf(&src, "func %s%s(w *%s) *%s {\n", ptrHelper, gen.typeParamsDecl(), wrapper, composite)
+		fmt.Fprintf(&src, "if w == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "return &w.%s\n}\n\n", gen.composite.Name.Name)
+	}
+
+	if needsPtrs {
+		fmt.Fprintf(&src, "func %s%s(ws []*%s) []*%s {\n", ptrSliceHelper, gen.typeParamsDecl(), wrapper, composite)
+		fmt.Fprintf(&src, "if ws == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "es := make([]*%s, len(ws))\n", composite)
+		fmt.Fprintf(&src, "for i, w := range ws {\nes[i] = %s(w)\n}\n", ptrHelper)
+		fmt.Fprintf(&src, "return es\n}\n\n")
+	}
+
+	if needsMap {
+		typeParams, key := gen.mapHelperTypeParams()
+		fmt.Fprintf(&src, "func %s%s(ws map[%s]%s) map[%s]%s {\n", mapHelper, typeParams, key, wrapper, key, composite)
+		fmt.Fprintf(&src, "if ws == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "es := make(map[%s]%s, len(ws))\n", key, composite)
+		fmt.Fprintf(&src, "for key, w := range ws {\nes[key] = w.%s\n}\n", gen.composite.Name.Name)
+		fmt.Fprintf(&src, "return es\n}\n")
+	}
+
+	return gen.parseDecls(src.String())
+}
+
+// checkJSONKeys makes sure no field of a variant is marshalled under the key of
+// the "type" field naming the variant. Since decoding matches the keys
+// case-insensitively, the fields can't use "Type" either.
+func (gen *generator) checkJSONKeys(variant *ast.TypeSpec, method *ast.Field) error {
+	for _, field := range gen.variantFields(variant) {
+		key := field.Name
+		if field.Tag != nil {
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return err
+			}
+			name, _, _ := strings.Cut(reflect.StructTag(tag).Get("json"), ",")
+			switch name {
+			case "-":
+				continue
+			case "":
+			default:
+				key = name
+			}
+		}
+
+		if strings.EqualFold(key, "type") {
+			return gen.errorAt(method,
+				"field %s of variant %s would be marshalled as %q, which clashes with the \"type\" field naming the variant",
+				field.Name, variant.Name.Name, key)
+		}
+	}
+	return nil
+}
+
+// jsonDiscriminator picks the name of the field holding the variant name in the
+// struct a variant gets marshalled through, so that it doesn't clash with the
+// variant fields.
+func (gen *generator) jsonDiscriminator(variant *ast.TypeSpec) string {
+	used := make(map[string]bool)
+	for _, field := range gen.variantFields(variant) {
+		used[field.Name] = true
+	}
+
+	name := "Type"
+	for used[name] {
+		name += "_"
+	}
+	return name
+}
+
+// jsonField is a field declaration for one of the structs the JSON is
+// marshalled through.
+func jsonField(name, typ string, tag *ast.BasicLit) string {
+	if tag == nil {
+		return name + " " + typ
+	}
+	return name + " " + typ + " " + tag.Value
+}
@@ -0,0 +1,73 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateKind builds an enum with a constant per variant, and a Kind method
+// on each variant returning its constant, eg.
+//
+//	type ExprKind int
+//
+//	const (
+//		LitKind ExprKind = iota
+//		AddKind
+//	)
+//
+//	func (e *Lit) Kind() ExprKind { return LitKind }
+//
+// The enum also gets a String method, naming the variants. For code switching
+// on the kind of a composite value, the composite should declare the method
+// too.
+func (gen *generator) generateKind(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	kind := gen.composite.Name.Name + "Kind"
+	recv := gen.receiverName()
+
+	var src strings.Builder
+
+	fmt.Fprintf(&src, "type %s int\n\n", kind)
+
+	fmt.Fprintf(&src, "const (\n")
+	for i, variant := range variants {
+		if i == 0 {
+			fmt.Fprintf(&src, "%sKind %s = iota\n", variant.Name.Name, kind)
+		} else {
+			fmt.Fprintf(&src, "%sKind\n", variant.Name.Name)
+		}
+	}
+	fmt.Fprintf(&src, ")\n\n")
+
+	for _, variant := range variants {
+		fmt.Fprintf(&src, "func (%s %s) Kind() %s { return %sKind }\n", recv, gen.variantRecv(variant), kind, variant.Name.Name)
+	}
+	fmt.Fprintf(&src, "\n")
+
+	fmt.Fprintf(&src, "func (k %s) String() string {\nswitch k {\n", kind)
+	for _, variant := range variants {
+		fmt.Fprintf(&src, "case %sKind:\nreturn %q\n", variant.Name.Name, variant.Name.Name)
+	}
+	fmt.Fprintf(&src, "}\nreturn %s(\"%s(%%d)\", int(k))\n}\n", gen.qualified("fmt", "Sprintf"), kind)
+
+	return gen.parseDecls(src.String())
+}
+
+// generateTag builds a Tag method on each variant, returning the variant name,
+// eg.
+//
+//	func (*Lit) Tag() string { return "Lit" }
+//
+// That's handy for logging which variant a value is, when the composite
+// declares the method too.
+func (gen *generator) generateTag(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	var src strings.Builder
+	for _, variant := range variants {
+		fmt.Fprintf(&src, "func (%s) Tag() string { return %q }\n", gen.variantRecv(variant), variant.Name.Name)
+	}
+	return gen.parseDecls(src.String())
+}
@@ -0,0 +1,36 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import "strings"
+
+// The license notices LicenseHeader has shortcuts for, by name.
+var licenseNotices = map[string]string{
+	"mpl2": `This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at http://mozilla.org/MPL/2.0/.`,
+
+	"apache2": `Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.`,
+}
+
+// licenseComment renders the license header as comment lines: the notice of a
+// known license, or an SPDX identifier line for anything else.
+func licenseComment(license string) string {
+	notice, ok := licenseNotices[strings.ToLower(license)]
+	if !ok {
+		notice = "SPDX-License-Identifier: " + license
+	}
+	return headerComment(notice)
+}
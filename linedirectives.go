@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// lineReset marks where the output switches back from the source positions to
+// its own. The line number is only known once the whole file is formatted.
+const lineReset = "/*irgen:line-reset*/"
+
+// withLineDirectives adds line directives to a printed declaration, when it's
+// a variant type. Each field line then refers to the consumer method the field
+// comes from, eg.
+//
+//	type Lit struct {
+//		/*line expr.go:14*/ N int /*irgen:line-reset*/
+//	}
+//
+// NOTE: A //line directive has to start a line, which gofmt would not leave
+// it at within a struct. So the /*line*/ form is used instead.
+func (gen *generator) withLineDirectives(decl ast.Decl, printed []byte) []byte {
+	spec := variantSpec(decl)
+	pos, ok := gen.sourceLines[spec]
+	if !ok {
+		return printed
+	}
+
+	lines := bytes.Split(printed, []byte("\n"))
+	if len(lines) < 3 {
+		return printed
+	}
+
+	directive := fmt.Sprintf("/*line %s:%d*/ ", gen.relativeToOutput(pos.Filename), pos.Line)
+	fields := lines[1 : len(lines)-1]
+	for i, line := range fields {
+		indent := len(line) - len(bytes.TrimLeft(line, "\t"))
+		fields[i] = []byte(string(line[:indent]) + directive + string(line[indent:]))
+	}
+	last := len(fields) - 1
+	fields[last] = append(fields[last], " "+lineReset...)
+
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// resolveLineResets turns the line reset markers in the formatted output into
+// directives referring back to the output file. Since the character right after
+// the directive is the end of the line, that's given the line's own number.
+func (gen *generator) resolveLineResets(src []byte) []byte {
+	if !bytes.Contains(src, []byte(lineReset)) {
+		return src
+	}
+
+	name := filepath.Base(gen.outputFile())
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		reset := fmt.Sprintf("/*line %s:%d*/", name, i+1)
+		lines[i] = strings.Replace(line, lineReset, reset, 1)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// variantSpec returns the type spec of a declaration holding a single one.
+func variantSpec(decl ast.Decl) *ast.TypeSpec {
+	gen, ok := decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.TYPE || len(gen.Specs) != 1 {
+		return nil
+	}
+	spec, _ := gen.Specs[0].(*ast.TypeSpec)
+	return spec
+}
+
+// outputFile is the name of the file the generated code goes to, relative to
+// the source directory.
+func (gen *generator) outputFile() string {
+	if gen.OutputFile != "" {
+		return gen.OutputFile
+	}
+	return gen.DefaultOutputName()
+}
+
+// relativeToOutput turns the name of a source file into one relative to the
+// directory of the output file, since that's what line directives are resolved
+// against. When there's no telling, the name is kept as it is.
+func (gen *generator) relativeToOutput(filename string) string {
+	outDir := filepath.Dir(gen.outputFile())
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(gen.Directory, outDir)
+	}
+
+	absOut, err := filepath.Abs(outDir)
+	if err != nil {
+		return filename
+	}
+	absSrc, err := filepath.Abs(filename)
+	if err != nil {
+		return filename
+	}
+	rel, err := filepath.Rel(absOut, absSrc)
+	if err != nil {
+		return filename
+	}
+	return filepath.ToSlash(rel)
+}
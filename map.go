@@ -0,0 +1,93 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateMap builds a function rebuilding a tree of composite values
+// bottom-up, eg.
+//
+//	func MapExpr(e Expr, f func(Expr) Expr) Expr {
+//		switch v := e.(type) {
+//		case *Add:
+//			e = &Add{Left: MapExpr(v.Left, f), Right: MapExpr(v.Right, f)}
+//		}
+//		return f(e)
+//	}
+//
+// The children -- in fields of the composite type, or slices or maps of it --
+// are mapped first, then the variant is rebuilt from them and passed to f
+// itself. Variants without children are passed to f as they are, and so are
+// nil values.
+func (gen *generator) generateMap(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	mapper := "Map" + gen.composite.Name.Name
+	sliceHelper := "map" + gen.composite.Name.Name + "s"
+	mapHelper := "map" + gen.composite.Name.Name + "Map"
+
+	var (
+		cases                strings.Builder
+		needsSlice, needsMap bool
+	)
+	for _, variant := range variants {
+		var (
+			elts     []string
+			children bool
+		)
+		for _, field := range gen.variantFields(variant) {
+			value := "v." + field.Name
+
+			switch field.Kind {
+			case compositeField:
+				children = true
+				value = fmt.Sprintf("%s(%s, f)", mapper, value)
+			case compositeSliceField:
+				children, needsSlice = true, true
+				value = fmt.Sprintf("%s(%s, f)", sliceHelper, value)
+			case compositeMapField:
+				children, needsMap = true, true
+				value = fmt.Sprintf("%s(%s, f)", mapHelper, value)
+			}
+
+			elts = append(elts, field.Name+": "+value)
+		}
+		if !children {
+			continue
+		}
+
+		lit := fmt.Sprintf("%s{%s}", gen.variantType(variant), strings.Join(elts, ", "))
+		fmt.Fprintf(&cases, "case %s:\ne = %s\n", gen.variantRecv(variant), gen.variantValue(lit))
+	}
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "func %s%s(e %s, f func(%s) %s) %s {\n", mapper, gen.typeParamsDecl(), composite, composite, composite, composite)
+	if cases.Len() > 0 {
+		fmt.Fprintf(&src, "switch v := e.(type) {\n%s}\n", cases.String())
+	}
+	fmt.Fprintf(&src, "return f(e)\n}\n\n")
+
+	if needsSlice {
+		fmt.Fprintf(&src, "func %s%s(es []%s, f func(%s) %s) []%s {\n", sliceHelper, gen.typeParamsDecl(), composite, composite, composite, composite)
+		fmt.Fprintf(&src, "if es == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "mapped := make([]%s, len(es))\n", composite)
+		fmt.Fprintf(&src, "for i, e := range es {\nmapped[i] = %s(e, f)\n}\n", mapper)
+		fmt.Fprintf(&src, "return mapped\n}\n\n")
+	}
+
+	if needsMap {
+		typeParams, key := gen.mapHelperTypeParams()
+		fmt.Fprintf(&src, "func %s%s(es map[%s]%s, f func(%s) %s) map[%s]%s {\n", mapHelper, typeParams, key, composite, composite, composite, key, composite)
+		fmt.Fprintf(&src, "if es == nil {\nreturn nil\n}\n")
+		fmt.Fprintf(&src, "mapped := make(map[%s]%s, len(es))\n", key, composite)
+		fmt.Fprintf(&src, "for key, e := range es {\nmapped[key] = %s(e, f)\n}\n", mapper)
+		fmt.Fprintf(&src, "return mapped\n}\n")
+	}
+
+	return gen.parseDecls(src.String())
+}
@@ -0,0 +1,165 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// generateMatch builds a function that destructures a composite value by
+// calling one of the handler functions it's passed, eg.
+//
+//	func MatchExpr(e Expr, onLit func(n int), onVar func(name string)) {
+//		e.FeedTo(exprMatcher{onLit: onLit, onVar: onVar})
+//	}
+//
+// together with the consumer implementation it needs.
+func (gen *generator) generateMatch() []ast.Decl {
+	composite := gen.composite.Name.Name
+	matcherName := paramName(composite) + "Matcher"
+
+	var (
+		handlerFields []*ast.Field
+		matchParams   []*ast.Field
+		handlerElts   []ast.Expr
+		decls         []ast.Decl
+	)
+
+	valueName := &ast.Ident{Name: "e"}
+	matchParams = append(matchParams, &ast.Field{
+		Names: []*ast.Ident{valueName},
+		Type:  gen.instantiate(composite),
+	})
+
+	for _, method := range gen.variants {
+		variant := method.Names[0].Name
+		handlerName := "on" + variant
+		methodType := method.Type.(*ast.FuncType)
+
+		handlerType := &ast.FuncType{
+			Params:  lowercaseParams(methodType.Params),
+			Results: methodType.Results,
+		}
+
+		handlerFields = append(handlerFields, &ast.Field{
+			Names: []*ast.Ident{{Name: handlerName}},
+			Type:  handlerType,
+		})
+		matchParams = append(matchParams, &ast.Field{
+			Names: []*ast.Ident{{Name: handlerName}},
+			Type:  handlerType,
+		})
+		handlerElts = append(handlerElts, &ast.KeyValueExpr{
+			Key:   &ast.Ident{Name: handlerName},
+			Value: &ast.Ident{Name: handlerName},
+		})
+
+		// The consumer method parameters are exported, so they can't collide
+		// with the receiver name.
+		recvName := &ast.Ident{Name: "m"}
+		call := &ast.CallExpr{
+			Fun:  &ast.SelectorExpr{X: recvName, Sel: &ast.Ident{Name: handlerName}},
+			Args: paramNames(methodType.Params),
+		}
+		spreadVariadic(call, methodType.Params)
+
+		decls = append(decls, &ast.FuncDecl{
+			Recv: &ast.FieldList{List: []*ast.Field{{
+				Names: []*ast.Ident{recvName},
+				Type:  gen.instantiate(matcherName),
+			}}},
+			Name: &ast.Ident{Name: variant},
+			Type: &ast.FuncType{
+				Params:  copyFieldList(methodType.Params),
+				Results: methodType.Results,
+			},
+			Body: forwardingBody(call, methodType.Results.NumFields() > 0),
+		})
+	}
+
+	matcher := &ast.GenDecl{
+		Tok: token.TYPE,
+		Specs: []ast.Spec{&ast.TypeSpec{
+			Name:       &ast.Ident{Name: matcherName},
+			TypeParams: gen.typeParams(),
+			Type:       &ast.StructType{Fields: &ast.FieldList{List: handlerFields}},
+		}},
+	}
+
+	destructuring := gen.destructuring.Type.(*ast.FuncType)
+	matcherValue := &ast.CompositeLit{
+		Type: gen.instantiate(matcherName),
+		Elts: handlerElts,
+	}
+	call := &ast.CallExpr{
+		Fun:  &ast.SelectorExpr{X: valueName, Sel: &ast.Ident{Name: gen.destructuring.Names[0].Name}},
+		Args: []ast.Expr{matcherValue},
+	}
+
+	body := forwardingBody(call, destructuring.Results.NumFields() > 0)
+	if gen.consumerByPointer() {
+		// NOTE: The matcher has to be stored in a consumer variable to
+		// take a pointer to it.
+		consumerName := &ast.Ident{Name: "consumer"}
+		call.Args = []ast.Expr{&ast.UnaryExpr{Op: token.AND, X: consumerName}}
+		body.List = append([]ast.Stmt{&ast.DeclStmt{Decl: &ast.GenDecl{
+			Tok: token.VAR,
+			Specs: []ast.Spec{&ast.ValueSpec{
+				Names:  []*ast.Ident{{Name: consumerName.Name}},
+				Type:   gen.instantiate(gen.TypeNames.Consumer),
+				Values: []ast.Expr{matcherValue},
+			}},
+		}}}, body.List...)
+	}
+
+	match := &ast.FuncDecl{
+		Name: &ast.Ident{Name: "Match" + composite},
+		Type: &ast.FuncType{
+			TypeParams: gen.typeParams(),
+			Params:     &ast.FieldList{List: matchParams},
+			Results:    destructuring.Results,
+		},
+		Body: body,
+	}
+
+	return append([]ast.Decl{match, matcher}, decls...)
+}
+
+// forwardingBody builds a function body that makes the call, returning its
+// result if there is one.
+func forwardingBody(call *ast.CallExpr, returns bool) *ast.BlockStmt {
+	if returns {
+		return &ast.BlockStmt{
+			List: []ast.Stmt{&ast.ReturnStmt{Results: []ast.Expr{call}}},
+		}
+	}
+	return &ast.BlockStmt{
+		List: []ast.Stmt{&ast.ExprStmt{X: call}},
+	}
+}
+
+// lowercaseParams copies a parameter list, turning the names into unexported
+// ones.
+func lowercaseParams(params *ast.FieldList) *ast.FieldList {
+	copied := copyFieldList(params)
+	for _, field := range copied.List {
+		for _, name := range field.Names {
+			name.Name = paramName(name.Name)
+		}
+	}
+	return copied
+}
+
+// paramNames lists the names in a parameter list as expressions.
+func paramNames(params *ast.FieldList) []ast.Expr {
+	var names []ast.Expr
+	for _, field := range params.List {
+		for _, name := range field.Names {
+			names = append(names, &ast.Ident{Name: name.Name})
+		}
+	}
+	return names
+}
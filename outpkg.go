@@ -0,0 +1,215 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// outputPackage is the name of the package the generated code belongs to.
+func (gen *generator) outputPackage() string {
+	if gen.OutputPackageName != "" {
+		return gen.OutputPackageName
+	}
+	return gen.PackageName
+}
+
+// generatesElsewhere tells whether the generated code goes to a package other
+// than the source one. An import path set for the source package means it does,
+// even if the two packages are named the same.
+func (gen *generator) generatesElsewhere() bool {
+	return gen.CompositeImportPath != "" || gen.outputPackage() != gen.PackageName
+}
+
+// qualifySourceNames makes the declarations refer to the types of the source
+// package through an import, for when they go to a different package.
+//
+// NOTE: Only type expressions are rewritten, since that's the only place the
+// generated code refers to the source package from.
+func (gen *generator) qualifySourceNames(decls []ast.Decl) error {
+	importPath := gen.CompositeImportPath
+	if importPath == "" {
+		var err error
+		importPath, err = sourceImportPath(gen.Directory)
+		if err != nil {
+			return err
+		}
+	}
+	if gen.imports == nil {
+		gen.imports = make(map[string]importSpec)
+	}
+	if _, ok := gen.imports[importPath]; !ok && defaultPackageName(importPath) != gen.PackageName {
+		gen.imports[importPath] = importSpec{Name: gen.PackageName, Path: importPath}
+	}
+
+	var qualErr error
+	qualify := func(typ ast.Expr) ast.Expr {
+		if typ == nil || qualErr != nil {
+			return typ
+		}
+		typ, qualErr = gen.qualifyType(importPath, gen.declared, typ)
+		return typ
+	}
+
+	for _, decl := range decls {
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.Field:
+				node.Type = qualify(node.Type)
+			case *ast.CompositeLit:
+				node.Type = qualify(node.Type)
+			case *ast.TypeAssertExpr:
+				node.Type = qualify(node.Type)
+			case *ast.ValueSpec:
+				node.Type = qualify(node.Type)
+			case *ast.TypeSpec:
+				node.Type = qualify(node.Type)
+			}
+			return qualErr == nil
+		})
+	}
+	return qualErr
+}
+
+// qualifyType returns a type expression in which the names declared by the
+// package with the import path are qualified.
+//
+// The expression is not modified, since parts of it might be shared with the
+// source AST. Where anything changes, new nodes are built.
+func (gen *generator) qualifyType(importPath string, declared func(string) bool, typ ast.Expr) (ast.Expr, error) {
+	var err error
+	qualify := func(typ ast.Expr) ast.Expr {
+		if typ == nil || err != nil {
+			return typ
+		}
+		typ, err = gen.qualifyType(importPath, declared, typ)
+		return typ
+	}
+
+	switch typ := typ.(type) {
+	case *ast.Ident:
+		if !declared(typ.Name) || gen.isTypeParam(typ.Name) {
+			return typ, nil
+		}
+		if !ast.IsExported(typ.Name) {
+			return nil, gen.errorAt(typ, "%s is not exported, so package %s can't refer to it", typ.Name, gen.outputPackage())
+		}
+		qualified := gen.qualified(importPath, typ.Name)
+		if i := strings.Index(qualified, "."); i >= 0 {
+			return &ast.SelectorExpr{X: ast.NewIdent(qualified[:i]), Sel: ast.NewIdent(qualified[i+1:])}, nil
+		}
+		return ast.NewIdent(qualified), nil
+
+	case *ast.StarExpr:
+		return &ast.StarExpr{X: qualify(typ.X)}, err
+	case *ast.ParenExpr:
+		return &ast.ParenExpr{X: qualify(typ.X)}, err
+	case *ast.Ellipsis:
+		return &ast.Ellipsis{Elt: qualify(typ.Elt)}, err
+	case *ast.ArrayType:
+		return &ast.ArrayType{Len: typ.Len, Elt: qualify(typ.Elt)}, err
+	case *ast.MapType:
+		return &ast.MapType{Key: qualify(typ.Key), Value: qualify(typ.Value)}, err
+	case *ast.ChanType:
+		return &ast.ChanType{Begin: typ.Begin, Arrow: typ.Arrow, Dir: typ.Dir, Value: qualify(typ.Value)}, err
+
+	case *ast.IndexExpr:
+		return &ast.IndexExpr{X: qualify(typ.X), Index: qualify(typ.Index)}, err
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, len(typ.Indices))
+		for i, index := range typ.Indices {
+			indices[i] = qualify(index)
+		}
+		return &ast.IndexListExpr{X: qualify(typ.X), Indices: indices}, err
+
+	case *ast.FuncType:
+		funtyp := copyFuncType(typ)
+		err = gen.qualifyFields(importPath, declared, funtyp.Params)
+		if err == nil {
+			err = gen.qualifyFields(importPath, declared, funtyp.Results)
+		}
+		return funtyp, err
+	case *ast.StructType:
+		fields := copyFieldList(typ.Fields)
+		return &ast.StructType{Struct: typ.Struct, Fields: fields}, gen.qualifyFields(importPath, declared, fields)
+	case *ast.InterfaceType:
+		methods := copyFieldList(typ.Methods)
+		return &ast.InterfaceType{Interface: typ.Interface, Methods: methods}, gen.qualifyFields(importPath, declared, methods)
+
+	default:
+		// Selectors already refer to other packages.
+		return typ, nil
+	}
+}
+
+// qualifyFields qualifies the types of fields in a list the caller owns.
+func (gen *generator) qualifyFields(importPath string, declared func(string) bool, fields *ast.FieldList) error {
+	if fields == nil {
+		return nil
+	}
+	for _, field := range fields.List {
+		typ, err := gen.qualifyType(importPath, declared, field.Type)
+		if err != nil {
+			return err
+		}
+		field.Type = typ
+	}
+	return nil
+}
+
+// sourceImportPath finds the import path of the package in a directory. Within
+// a module that's worked out from the go.mod file, otherwise from GOPATH.
+func sourceImportPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("can't find the import path of the package in %q: %w", dir, err)
+	}
+
+	for root := abs; ; root = filepath.Dir(root) {
+		module, ok := modulePath(filepath.Join(root, "go.mod"))
+		if ok {
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				return "", fmt.Errorf("can't find the import path of the package in %q: %w", dir, err)
+			}
+			return path.Join(module, filepath.ToSlash(rel)), nil
+		}
+
+		if filepath.Dir(root) == root {
+			break
+		}
+	}
+
+	pkg, err := build.ImportDir(abs, build.FindOnly)
+	if err != nil || pkg.ImportPath == "" || pkg.ImportPath == "." {
+		return "", fmt.Errorf("can't find the import path of the package in %q: it's neither in a module nor in GOPATH", dir)
+	}
+	return pkg.ImportPath, nil
+}
+
+// modulePath reads the module path out of a go.mod file, if there is one.
+func modulePath(gomod string) (string, bool) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,123 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// passthroughParams reads the names of the passthrough parameters off the doc
+// comment of the consumer. Each consumer method takes them first, and they're
+// not stored in the variants -- the destructuring method takes them before the
+// consumer and passes them on instead:
+//
+//	//irgen:passthrough Ctx
+//	type ExprConsumer interface {
+//		Lit(Ctx context.Context, N int)
+//	}
+func (gen *generator) passthroughParams() ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, comment := range directives(gen.typeDoc(gen.consumerPackage(), gen.consumer)) {
+		name, args, _ := directive(comment)
+		if name != "passthrough" {
+			continue
+		}
+
+		params := strings.Fields(args)
+		if len(params) == 0 {
+			return nil, gen.errorAt(comment, "%spassthrough names no parameters", directivePrefix)
+		}
+		for _, param := range params {
+			switch {
+			case !token.IsIdentifier(param):
+				return nil, gen.errorAt(comment, "passthrough parameter %q is not an identifier", param)
+			case seen[param]:
+				return nil, gen.errorAt(comment, "passthrough parameter %s is given more than once", param)
+			case paramName(param) == "consumer" || paramName(param) == "acc":
+				return nil, gen.errorAt(comment, "passthrough parameter %s would collide with the %s parameter", param, paramName(param))
+			}
+			seen[param] = true
+			names = append(names, param)
+		}
+	}
+	return names, nil
+}
+
+// typeDoc finds the doc comment of a type spec. For a lone spec the parser
+// attaches it to the declaration instead.
+func (gen *generator) typeDoc(pkg *ast.Package, spec *ast.TypeSpec) *ast.CommentGroup {
+	if spec.Doc != nil {
+		return spec.Doc
+	}
+
+	f := fileContaining(pkg, spec)
+	if f == nil {
+		return nil
+	}
+	for _, decl := range f.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if ok && len(decl.Specs) == 1 && decl.Specs[0] == spec {
+			return decl.Doc
+		}
+	}
+	return nil
+}
+
+// withoutPassthrough checks that a consumer method takes the passthrough
+// parameters first, with the same types the destructuring method does, and
+// returns a copy of it without them.
+func (gen *generator) withoutPassthrough(compositeMethod, method *ast.Field) (*ast.Field, error) {
+	if len(gen.passthrough) == 0 {
+		return method, nil
+	}
+
+	typ := method.Type.(*ast.FuncType)
+	want := paramTypes(compositeMethod.Type.(*ast.FuncType).Params)
+	fields := copyFieldList(typ.Params)
+
+	for i, param := range gen.passthrough {
+		if len(fields.List) == 0 || len(fields.List[0].Names) == 0 ||
+			fields.List[0].Names[0].Name != param ||
+			types.ExprString(fields.List[0].Type) != types.ExprString(want[i]) {
+
+			return nil, gen.errorAt(method,
+				"consumer method %s should take %s %s as its argument number %d",
+				method.Names[0].Name, param, types.ExprString(want[i]), i+1)
+		}
+
+		first := fields.List[0]
+		if len(first.Names) > 1 {
+			first.Names = first.Names[1:]
+		} else {
+			fields.List = fields.List[1:]
+		}
+	}
+
+	return &ast.Field{
+		Doc:     method.Doc,
+		Names:   method.Names,
+		Type:    &ast.FuncType{TypeParams: typ.TypeParams, Params: fields, Results: typ.Results},
+		Comment: method.Comment,
+	}, nil
+}
+
+// destructuringParamName names the parameter of the generated destructuring
+// methods at the index: the passthrough parameters, the consumer and the
+// accumulator, in that order.
+func (gen *generator) destructuringParamName(i int) string {
+	switch n := len(gen.passthrough); {
+	case i < n:
+		return paramName(gen.passthrough[i])
+	case i == n:
+		return "consumer"
+	default:
+		return "acc"
+	}
+}
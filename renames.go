@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// fieldRenames reads the names the variant fields get in place of the
+// parameter names off the consumer method. Each is given by a directive in the
+// doc or the line comment of the method, like tags are:
+//
+//	Add(Left, Right Expr) //irgen:field Left L
+func (gen *generator) fieldRenames(method *ast.Field) (map[string]string, error) {
+	params := make(map[string]bool)
+	for _, field := range method.Type.(*ast.FuncType).Params.List {
+		for _, name := range field.Names {
+			params[name.Name] = true
+		}
+	}
+
+	renames := make(map[string]string)
+	taken := make(map[string]string)
+	for _, comment := range directives(method.Doc, method.Comment) {
+		name, args, _ := directive(comment)
+		if name != "field" {
+			continue
+		}
+
+		fields := strings.Fields(args)
+		if len(fields) != 2 {
+			return nil, gen.errorAt(comment, "%sfield should name a parameter and the field name for it", directivePrefix)
+		}
+		param, field := fields[0], fields[1]
+
+		switch {
+		case !params[param]:
+			return nil, gen.errorAt(comment, "consumer method %s has no parameter %q to rename", method.Names[0].Name, param)
+		case renames[param] != "":
+			return nil, gen.errorAt(comment, "parameter %s of consumer method %s is renamed more than once", param, method.Names[0].Name)
+		case !token.IsIdentifier(field) || !token.IsExported(field):
+			return nil, gen.errorAt(comment, "field name %q for parameter %s of consumer method %s is not an exported identifier", field, param, method.Names[0].Name)
+		case taken[field] != "":
+			return nil, gen.errorAt(comment, "parameters %s and %s of consumer method %s would both become field %s", taken[field], param, method.Names[0].Name, field)
+		}
+		renames[param] = field
+		taken[field] = param
+	}
+
+	for _, field := range method.Type.(*ast.FuncType).Params.List {
+		for _, name := range field.Names {
+			if other, ok := taken[name.Name]; ok && renames[name.Name] == "" {
+				return nil, gen.errorAt(method, "parameters %s and %s of consumer method %s would both become field %s", other, name.Name, method.Names[0].Name, name.Name)
+			}
+		}
+	}
+	return renames, nil
+}
+
+// withRenamedFields returns a copy of a consumer method with the parameters
+// renamed, along with the tags keyed by the new names.
+func withRenamedFields(method *ast.Field, renames, tags map[string]string) (*ast.Field, map[string]string) {
+	if len(renames) == 0 {
+		return method, tags
+	}
+
+	typ := method.Type.(*ast.FuncType)
+	fields := copyFieldList(typ.Params)
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			if renamed, ok := renames[name.Name]; ok {
+				name.Name = renamed
+			}
+		}
+	}
+
+	renamedTags := make(map[string]string, len(tags))
+	for param, tag := range tags {
+		if renamed, ok := renames[param]; ok {
+			param = renamed
+		}
+		renamedTags[param] = tag
+	}
+
+	return &ast.Field{
+		Doc:     method.Doc,
+		Names:   method.Names,
+		Type:    &ast.FuncType{TypeParams: typ.TypeParams, Params: fields, Results: typ.Results},
+		Comment: method.Comment,
+	}, renamedTags
+}
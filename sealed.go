@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// findSealMethod picks the marker method sealing the composite out of the
+// composite interface methods, returning the rest of them. A marker method is
+// unexported and has neither parameters nor results. It can also come from an
+// embedded unexported interface declaring only it, like exprSealed in
+//
+//	type Expr interface {
+//		exprSealed
+//		FeedTo(cons ExprConsumer)
+//	}
+//
+// When there's none, the marker is named after the composite.
+func (gen *generator) findSealMethod(methods []*ast.Field) []*ast.Field {
+	gen.sealMethod = "is" + gen.TypeNames.Composite
+
+	var rest []*ast.Field
+	for _, method := range methods {
+		if isSealMethod(method) {
+			gen.sealMethod = method.Names[0].Name
+			continue
+		}
+		if marker, ok := gen.embeddedSealMethod(method); ok {
+			gen.sealMethod = marker
+			continue
+		}
+		rest = append(rest, method)
+	}
+	return rest
+}
+
+// embeddedSealMethod tells the name of the marker method when the field embeds
+// an unexported interface of the source package with only that method.
+func (gen *generator) embeddedSealMethod(field *ast.Field) (string, bool) {
+	ident, ok := field.Type.(*ast.Ident)
+	if !ok || len(field.Names) != 0 || ident.IsExported() {
+		return "", false
+	}
+
+	spec, err := typeSpecNamed(gen.pkg, ident.Name)
+	if err != nil || spec.TypeParams != nil {
+		return "", false
+	}
+	iface, ok := spec.Type.(*ast.InterfaceType)
+	if !ok || len(iface.Methods.List) != 1 || !isSealMethod(iface.Methods.List[0]) {
+		return "", false
+	}
+	return iface.Methods.List[0].Names[0].Name, true
+}
+
+func isSealMethod(method *ast.Field) bool {
+	typ, ok := method.Type.(*ast.FuncType)
+	if !ok || len(method.Names) != 1 || method.Names[0].IsExported() {
+		return false
+	}
+	return typ.Params.NumFields() == 0 && typ.Results.NumFields() == 0
+}
+
+// generateSeal builds the marker method for each variant, eg.
+//
+//	func (*Lit) isExpr() {}
+func (gen *generator) generateSeal(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	var src strings.Builder
+	for _, variant := range variants {
+		fmt.Fprintf(&src, "func (%s) %s() {}\n", gen.variantRecv(variant), gen.sealMethod)
+	}
+	return gen.parseDecls(src.String())
+}
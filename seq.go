@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateSeq builds a function returning an iterator over every node of a tree
+// of composite values, in pre-order, eg.
+//
+//	func ExprAll(e Expr) iter.Seq[Expr] {
+//		return func(yield func(Expr) bool) {
+//			yieldExpr(e, yield)
+//		}
+//	}
+//
+// along with the helper doing the traversal, which stops as soon as yield
+// returns false. The children are the same ones WalkExpr visits.
+func (gen *generator) generateSeq(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	all := gen.composite.Name.Name + "All"
+	helper := "yield" + gen.composite.Name.Name
+
+	visit := func(child string) string {
+		return fmt.Sprintf("if !%s(%s, yield) {\nreturn false\n}\n", helper, child)
+	}
+
+	cases := gen.walkCases(variants, visit)
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "func %s%s(e %s) %s[%s] {\n", all, gen.typeParamsDecl(), composite, gen.qualified("iter", "Seq"), composite)
+	fmt.Fprintf(&src, "return func(yield func(%s) bool) {\n%s(e, yield)\n}\n}\n\n", composite, helper)
+
+	fmt.Fprintf(&src, "func %s%s(e %s, yield func(%s) bool) bool {\n", helper, gen.typeParamsDecl(), composite, composite)
+	fmt.Fprintf(&src, "if e == nil {\nreturn true\n}\n")
+	fmt.Fprintf(&src, "if !yield(e) {\nreturn false\n}\n")
+	if cases != "" {
+		fmt.Fprintf(&src, "switch v := e.(type) {\n%s}\n", cases)
+	}
+	fmt.Fprintf(&src, "return true\n}\n")
+
+	return gen.parseDecls(src.String())
+}
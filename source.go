@@ -0,0 +1,25 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+)
+
+// parseDecls parses declarations out of Go source text. It's used for the
+// more involved pieces of generated code, which would be unwieldy to build
+// node by node.
+//
+// The source is added to the generator's file set, so the printer keeps the
+// line breaks it was written with.
+func (gen *generator) parseDecls(src string) ([]ast.Decl, error) {
+	f, err := parser.ParseFile(gen.fset, "", "package "+gen.PackageName+"\n\n"+src, 0)
+	if err != nil {
+		return nil, fmt.Errorf("irgen generated invalid code\n%s: %w", src, err)
+	}
+	return f.Decls, nil
+}
@@ -0,0 +1,78 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateString builds a String method for each variant, rendering it like
+// a keyed composite literal, eg.
+//
+//	Add{Left: Lit{N: 1}, Right: Var{Name: x}}
+//
+// Fields of the composite type (or slices of it) are rendered with their own
+// String methods, everything else with the %v verb.
+func (gen *generator) generateString(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	helper := "string" + gen.composite.Name.Name
+	sliceHelper := helper + "s"
+	recv := gen.receiverName()
+	sprintf := gen.qualified("fmt", "Sprintf")
+
+	var (
+		src        strings.Builder
+		needsSlice bool
+	)
+
+	fmt.Fprintf(&src, "func %s%s(e %s) string {\n", helper, gen.typeParamsDecl(), composite)
+	fmt.Fprintf(&src, "if s, ok := e.(%s); ok {\nreturn s.String()\n}\n", gen.qualified("fmt", "Stringer"))
+	fmt.Fprintf(&src, "return %s(\"%%v\", e)\n}\n\n", sprintf)
+
+	for _, variant := range variants {
+		var (
+			format []string
+			args   []string
+		)
+
+		for _, field := range gen.variantFields(variant) {
+			value := recv + "." + field.Name
+
+			switch field.Kind {
+			case compositeField:
+				format = append(format, field.Name+": %s")
+				args = append(args, helper+"("+value+")")
+			case compositeSliceField:
+				needsSlice = true
+				format = append(format, field.Name+": %s")
+				args = append(args, sliceHelper+"("+value+")")
+			default:
+				format = append(format, field.Name+": %v")
+				args = append(args, value)
+			}
+		}
+
+		fmt.Fprintf(&src, "func (%s %s) String() string {\n", recv, gen.variantRecv(variant))
+		if len(args) == 0 {
+			fmt.Fprintf(&src, "return %q\n}\n\n", variant.Name.Name+"{}")
+			continue
+		}
+		fmt.Fprintf(&src, "return %s(%q, %s)\n}\n\n",
+			sprintf,
+			variant.Name.Name+"{"+strings.Join(format, ", ")+"}",
+			strings.Join(args, ", "))
+	}
+
+	if needsSlice {
+		fmt.Fprintf(&src, "func %s%s(es []%s) string {\n", sliceHelper, gen.typeParamsDecl(), composite)
+		fmt.Fprintf(&src, "parts := make([]string, len(es))\n")
+		fmt.Fprintf(&src, "for i, e := range es {\nparts[i] = %s(e)\n}\n", helper)
+		fmt.Fprintf(&src, "return \"[\" + %s(parts, \" \") + \"]\"\n}\n\n", gen.qualified("strings", "Join"))
+	}
+
+	return gen.parseDecls(src.String())
+}
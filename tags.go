@@ -0,0 +1,113 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// fieldTags reads the struct tags of a variant's fields off its consumer
+// method. Each is given by a directive in the doc or the line comment of the
+// method, naming the parameter the tag is for:
+//
+//	Var(Name string) //irgen:tag Name json:"name"
+func (gen *generator) fieldTags(method *ast.Field) (map[string]string, error) {
+	params := make(map[string]bool)
+	for _, field := range method.Type.(*ast.FuncType).Params.List {
+		for _, name := range field.Names {
+			params[name.Name] = true
+		}
+	}
+
+	tags := make(map[string]string)
+	for _, comment := range directives(method.Doc, method.Comment) {
+		name, args, _ := directive(comment)
+		if name != "tag" {
+			continue
+		}
+
+		param, tag, _ := strings.Cut(args, " ")
+		tag = strings.TrimSpace(tag)
+		switch {
+		case !params[param]:
+			return nil, gen.errorAt(comment, "consumer method %s has no parameter %q to tag", method.Names[0].Name, param)
+		case tags[param] != "":
+			return nil, gen.errorAt(comment, "parameter %s of consumer method %s is tagged more than once", param, method.Names[0].Name)
+		}
+
+		err := checkStructTag(tag)
+		if err != nil {
+			return nil, gen.errorAt(comment, "bad tag for parameter %s of consumer method %s: %s", param, method.Names[0].Name, err)
+		}
+		tags[param] = tag
+	}
+	return tags, nil
+}
+
+// checkStructTag makes sure a tag follows the conventional format of
+// space-separated key:"value" pairs, which reflect.StructTag expects.
+func checkStructTag(tag string) error {
+	if tag == "" {
+		return errors.New("the tag is empty")
+	}
+
+	for tag != "" {
+		tag = strings.TrimLeft(tag, " ")
+		if tag == "" {
+			break
+		}
+
+		colon := strings.Index(tag, ":")
+		if colon <= 0 || strings.ContainsAny(tag[:colon], " \"") {
+			return fmt.Errorf("%q is not a key:\"value\" pair", tag)
+		}
+		tag = tag[colon+1:]
+
+		value, err := strconv.QuotedPrefix(tag)
+		if err != nil || value[0] != '"' {
+			return fmt.Errorf("the value in %q is not a quoted string", tag)
+		}
+		tag = tag[len(value):]
+	}
+	return nil
+}
+
+// tagLiteral is the string literal for a struct tag, raw when possible.
+func tagLiteral(tag string) *ast.BasicLit {
+	value := "`" + tag + "`"
+	if strings.Contains(tag, "`") {
+		value = strconv.Quote(tag)
+	}
+	return &ast.BasicLit{Kind: token.STRING, Value: value}
+}
+
+// taggedFields applies the tags to the fields of a variant. Once anything is
+// tagged, each name gets a field of its own, so that a tag applies to it alone.
+func taggedFields(fields []*ast.Field, tags map[string]string) []*ast.Field {
+	if len(tags) == 0 {
+		return fields
+	}
+
+	var tagged []*ast.Field
+	for _, field := range fields {
+		for _, name := range field.Names {
+			var tag *ast.BasicLit
+			if t, ok := tags[name.Name]; ok {
+				tag = tagLiteral(t)
+			}
+			tagged = append(tagged, &ast.Field{
+				Names: []*ast.Ident{{Name: name.Name}},
+				Type:  field.Type,
+				Tag:   tag,
+			})
+		}
+	}
+	return tagged
+}
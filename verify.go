@@ -0,0 +1,112 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/types"
+	"path/filepath"
+)
+
+// verify type checks the generated source together with the source package,
+// or against it, when the output goes to a different package.
+//
+// Files of the source package that declare any of the variant types (or any of
+// the functions and methods generated, for when only methods are) are left
+// out, since they're presumably older output that the generated source is
+// meant to replace.
+func (gen *generator) verify(src []byte) error {
+	filename := filepath.Join(gen.Directory, "irgen_output.go")
+	generated, err := parser.ParseFile(gen.fset, filename, src, 0)
+	if err != nil {
+		return fmt.Errorf("can't parse the generated code: %w", err)
+	}
+
+	replaced := make(map[string]bool)
+	for _, name := range declaredNames(generated) {
+		replaced[name] = true
+	}
+
+	files := []*ast.File{generated}
+	for _, f := range sortedFiles(gen.pkg) {
+		if !gen.generatesElsewhere() && !declaresAny(f, replaced) {
+			files = append(files, f)
+		}
+	}
+
+	var firstErr error
+	config := types.Config{
+		Importer: importer.ForCompiler(gen.fset, "source", nil),
+		Error: func(err error) {
+			if firstErr == nil {
+				firstErr = err
+			}
+		},
+	}
+	config.Check(gen.outputPackage(), gen.fset, files, nil)
+
+	if firstErr != nil {
+		return fmt.Errorf("generated code does not type check: %w", firstErr)
+	}
+	return nil
+}
+
+// declaresAny tells whether a file declares a type, a function or a method
+// with any of the names declaredNames gives.
+func declaresAny(f *ast.File, names map[string]bool) bool {
+	for _, name := range declaredNames(f) {
+		if names[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// declaredNames lists the names of the types, functions and methods declared
+// in a file. Methods are named like Lit.FeedTo.
+func declaredNames(f *ast.File) []string {
+	var names []string
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if spec, ok := spec.(*ast.TypeSpec); ok {
+					names = append(names, spec.Name.Name)
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				names = append(names, decl.Name.Name)
+			} else if recv := receiverTypeName(decl.Recv.List[0].Type); recv != "" {
+				names = append(names, recv+"."+decl.Name.Name)
+			}
+		}
+	}
+	return names
+}
+
+// receiverTypeName is the name of the type of a method receiver, like Lit for
+// *Lit or Tree for Tree[T].
+func receiverTypeName(typ ast.Expr) string {
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package irgen
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// generateWalk builds a function visiting every node of a tree of composite
+// values, eg.
+//
+//	func WalkExpr(e Expr, pre func(Expr) bool) {
+//		if e == nil || !pre(e) {
+//			return
+//		}
+//		switch v := e.(type) {
+//		case *Add:
+//			WalkExpr(v.Left, pre)
+//			WalkExpr(v.Right, pre)
+//		}
+//	}
+//
+// The children are the fields of the composite type and the elements of
+// slices or the values of maps of it -- the latter in no particular order.
+// Pointers to the composite, alone or in slices, are followed unless nil. The
+// children only get visited when pre returns true for their parent.
+func (gen *generator) generateWalk(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	walk := "Walk" + gen.composite.Name.Name
+
+	cases := gen.walkCases(variants, func(child string) string {
+		return fmt.Sprintf("%s(%s, pre)\n", walk, child)
+	})
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "func %s%s(e %s, pre func(%s) bool) {\n", walk, gen.typeParamsDecl(), composite, composite)
+	fmt.Fprintf(&src, "if e == nil || !pre(e) {\nreturn\n}\n")
+	if cases != "" {
+		fmt.Fprintf(&src, "switch v := e.(type) {\n%s}\n", cases)
+	}
+	fmt.Fprintf(&src, "}\n")
+
+	return gen.parseDecls(src.String())
+}
+
+// generateWalkParent builds a function like the one generateWalk does, but
+// passing pre the parent of each node along with it, eg.
+//
+//	func WalkExprWithParent(e Expr, pre func(node, parent Expr) bool) {
+//		walkExprWithParent(e, nil, pre)
+//	}
+//
+// The root has a nil parent. The helper doing the traversal threads the parent
+// through.
+func (gen *generator) generateWalkParent(variants []*ast.TypeSpec) ([]ast.Decl, error) {
+	composite := gen.compositeType()
+	walk := "Walk" + gen.composite.Name.Name + "WithParent"
+	helper := "walk" + gen.composite.Name.Name + "WithParent"
+	pre := fmt.Sprintf("func(node, parent %s) bool", composite)
+
+	cases := gen.walkCases(variants, func(child string) string {
+		return fmt.Sprintf("%s(%s, e, pre)\n", helper, child)
+	})
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "func %s%s(e %s, pre %s) {\n", walk, gen.typeParamsDecl(), composite, pre)
+	fmt.Fprintf(&src, "%s(e, nil, pre)\n}\n\n", helper)
+
+	fmt.Fprintf(&src, "func %s%s(e, parent %s, pre %s) {\n", helper, gen.typeParamsDecl(), composite, pre)
+	fmt.Fprintf(&src, "if e == nil || !pre(e, parent) {\nreturn\n}\n")
+	if cases != "" {
+		fmt.Fprintf(&src, "switch v := e.(type) {\n%s}\n", cases)
+	}
+	fmt.Fprintf(&src, "}\n")
+
+	return gen.parseDecls(src.String())
+}
+
+// walkCases builds the cases of a type switch on a node v, with visit called
+// on the source of each child of the variants that have any.
+func (gen *generator) walkCases(variants []*ast.TypeSpec, visit func(child string) string) string {
+	var cases strings.Builder
+	for _, variant := range variants {
+		var children strings.Builder
+		for _, field := range gen.variantFields(variant) {
+			switch field.Kind {
+			case compositeField:
+				children.WriteString(visit("v." + field.Name))
+			case compositeSliceField, compositeMapField:
+				fmt.Fprintf(&children, "for _, child := range v.%s {\n%s}\n", field.Name, visit("child"))
+			case compositePointerField:
+				fmt.Fprintf(&children, "if v.%s != nil {\n%s}\n", field.Name, visit("*v."+field.Name))
+			case compositePointerSliceField:
+				fmt.Fprintf(&children, "for _, child := range v.%s {\nif child != nil {\n%s}\n}\n", field.Name, visit("*child"))
+			}
+		}
+		if children.Len() > 0 {
+			fmt.Fprintf(&cases, "case %s:\n%s", gen.variantRecv(variant), children.String())
+		}
+	}
+	return cases.String()
+}